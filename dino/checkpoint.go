@@ -0,0 +1,80 @@
+package dino
+
+import "time"
+
+// ProcessCheckpoint is the execution state of a process, enough to recreate it in another Dino. Unlike a Process it
+// holds no pointers, so it can be serialized. Where the process was placed and the steps it was stamped with belong to
+// the Dino it ran in, so they're left out, but for Step, which the pending Resizes are kept relative to
+type ProcessCheckpoint struct {
+	Step           int // step of the Dino the checkpoint was taken at
+	ID             string
+	Name           string
+	Type           ProcessType
+	ProgramCounter int    // index of the current burst
+	Bursts         Bursts // bursts left to run
+	IOBurst        time.Duration
+	SizeInKB       int // size of its memory contents
+	AccessSequence []int
+	Weight         int
+	Nice           int
+	Resizes        []Resize
+	Tags           map[string]string
+	TouchPattern   TouchPattern
+	Pinned         bool
+	MaxAddress     int
+	ParentID       string
+}
+
+// Checkpoint captures the execution state of p at the current step of the Dino it runs in
+func (p *Process) Checkpoint() ProcessCheckpoint {
+	clone := p.Clone()
+	return ProcessCheckpoint{
+		Step:           clone.step,
+		ID:             clone.ID,
+		Name:           clone.Name,
+		Type:           clone.Type,
+		ProgramCounter: clone.ProgramCounter,
+		Bursts:         clone.Bursts,
+		IOBurst:        clone.IOBurst,
+		SizeInKB:       clone.SizeInKB,
+		AccessSequence: clone.AccessSequence,
+		Weight:         clone.Weight,
+		Nice:           clone.Nice,
+		Resizes:        clone.Resizes,
+		Tags:           clone.Tags,
+		TouchPattern:   clone.TouchPattern,
+		Pinned:         clone.Pinned,
+		MaxAddress:     clone.MaxAddress,
+		ParentID:       clone.ParentID(),
+	}
+}
+
+// ProcessFromCheckpoint recreates a process from its checkpoint, out of memory and ready to be injected in another
+// Dino, where its pending Resizes come as many steps after the injection as they came after the checkpoint. Its
+// parent isn't restored, as it may not exist where the process is migrated
+func ProcessFromCheckpoint(c ProcessCheckpoint) *Process {
+	p := &Process{
+		ID:             c.ID,
+		Name:           c.Name,
+		Type:           c.Type,
+		ProgramCounter: c.ProgramCounter,
+		Bursts:         c.Bursts,
+		IOBurst:        c.IOBurst,
+		SizeInKB:       c.SizeInKB,
+		AccessSequence: c.AccessSequence,
+		Weight:         c.Weight,
+		Nice:           c.Nice,
+		Resizes:        c.Resizes,
+		Tags:           c.Tags,
+		TouchPattern:   c.TouchPattern,
+		Pinned:         c.Pinned,
+		MaxAddress:     c.MaxAddress,
+		MemoryAddress:  -1,
+	}
+	p = p.Clone() // shares nothing with the checkpoint
+	for i := range p.Resizes {
+		p.Resizes[i].Step -= c.Step
+	}
+	p.relativeResizes = true
+	return p
+}
@@ -0,0 +1,75 @@
+package dino
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VerifyDeterminism runs two simulations of 1000 KB seeded with seed side by side for the given steps, and returns an
+// error describing the first step where their memory, queues, dispatched processes or events differ. It catches
+// reliance on anything but the seeded source, like the global rand or the iteration order of maps
+func VerifyDeterminism(seed int64, steps int) error {
+	return VerifyDeterminismWith(seed, steps, nil)
+}
+
+// VerifyDeterminismWith is VerifyDeterminism with both simulations configured by setup before the first step
+func VerifyDeterminismWith(seed int64, steps int, setup func(d *Dino)) error {
+	first, second := NewSeeded(1000, seed), NewSeeded(1000, seed)
+	if setup != nil {
+		setup(first)
+		setup(second)
+	}
+	for step := 1; step <= steps; step++ {
+		events := len(first.events)
+		if _, err := first.Step(); err != nil {
+			return err
+		} else if _, err := second.Step(); err != nil {
+			return err
+		}
+		expected, actual := first.fingerprint(events), second.fingerprint(events)
+		for i := range expected {
+			if expected[i] != actual[i] {
+				return fmt.Errorf("Nondeterministic -- at step %d, %s", step, describeDivergence(expected[i], actual[i]))
+			}
+		}
+	}
+	return nil
+}
+
+// fingerprint describes what an identically seeded Dino should reproduce: its memory, queues, the processes that ran
+// and the events logged since the given one. Each entry starts with what it describes
+func (d *Dino) fingerprint(sinceEvent int) []string {
+	ids := func(ps Processes) string {
+		list := []string{}
+		for _, p := range ps {
+			if p != nil {
+				list = append(list, p.ID)
+			}
+		}
+		return strings.Join(list, " ")
+	}
+	blocks := []string{}
+	for _, block := range d.Memory.Layout() {
+		blocks = append(blocks, fmt.Sprintf("[%d, %d) %s", block.Start, block.Start+block.Size, block.OwnerID))
+	}
+	events := []string{}
+	if sinceEvent <= len(d.events) {
+		for _, e := range d.events[sinceEvent:] {
+			events = append(events, fmt.Sprintf("%s %s %s", e.Type, e.ProcessID, e.Detail))
+		}
+	}
+	return []string{
+		"memory: " + strings.Join(blocks, ", "),
+		"new queue: " + ids(d.newQueue.Processes()),
+		"ready queue: " + ids(d.readyQueue.Processes()),
+		"CPU: " + ids(Processes{d.state.ExecutedByCPU}),
+		"IO: " + ids(d.state.ExecutedByIO),
+		"events: " + strings.Join(events, "; "),
+	}
+}
+
+// describeDivergence tells the two values of a fingerprint entry apart, e.g. "CPU: 'a' vs 'b'"
+func describeDivergence(expected, actual string) string {
+	parts := strings.SplitN(expected, ": ", 2)
+	return fmt.Sprintf("%s: '%s' vs '%s'", parts[0], parts[1], strings.TrimPrefix(actual, parts[0]+": "))
+}
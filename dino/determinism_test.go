@@ -0,0 +1,38 @@
+package dino
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// shuffledQueue queues processes at random places drawn from the global rand, which seeding the Dino doesn't cover
+type shuffledQueue struct {
+	*Queue
+}
+
+func (q shuffledQueue) Add(p *Process) error {
+	i := rand.Intn(len(q.processes) + 1)
+	q.processes = append(q.processes, nil)
+	copy(q.processes[i+1:], q.processes[i:])
+	q.processes[i] = p
+	return nil
+}
+
+func TestVerifyDeterminism(t *testing.T) {
+	assert.NoError(t, VerifyDeterminism(42, 100))
+	assert.NoError(t, VerifyDeterminismWith(7, 100, func(d *Dino) {
+		d.NumIODevices = 2
+		d.ContextSwitchSteps = 1
+	}))
+
+	err := VerifyDeterminismWith(42, 100, func(d *Dino) {
+		d.SetScheduler(shuffledQueue{NewQueue("Shuffled")})
+	})
+	if assert.Error(t, err) {
+		assert.True(t, strings.HasPrefix(err.Error(), "Nondeterministic -- at step "), err.Error())
+		assert.Contains(t, err.Error(), "' vs '")
+	}
+}
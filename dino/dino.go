@@ -1,7 +1,14 @@
 package dino
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
 )
 
 const (
@@ -9,24 +16,87 @@ const (
 )
 
 type Dino struct {
-	Memory     Memory
+	Memory          Memory
+	DisableIO       bool         // when set, every burst is treated as a CPU burst and the IO subsystem stays idle
+	DisableArrivals bool         // when set, only injected processes arrive
+	StallThreshold  int          // consecutive steps without progress before a stall event is logged, 0 or less disables it
+	Paging          *PagedMemory // when set, running processes reference the pages of their AccessSequence
+	RecordTimeline  bool         // when set, the memory map is captured at the end of every step
+	KeepHistory     bool         // when set, the whole simulator is saved at every step, so RewindTo can go back to it
+	BurstRules      BurstRules   // rules injected processes should follow
+	// ContextSwitchSteps is how many steps the CPU stays idle before running a process other than the last one
+	ContextSwitchSteps int
+	// NumIODevices is how many processes can do IO while the CPU runs another one. With 0, only one process runs
+	// per step, either on the CPU or the IO
+	NumIODevices int
+	// MemoryBandwidth is how many of the devices dispatched in a step, the CPU and each IO device, can reach memory
+	// and make their process progress. There's a single CPU, so the contention is between the devices: IO devices
+	// stand for the other processors. The processes past the limit hold their device without running, which extends
+	// their bursts by a step. With 0, bandwidth is unlimited
+	MemoryBandwidth int
+	// PrioritizeInteractive boosts interactive processes returning from IO, which run before any other ready process
+	PrioritizeInteractive bool
+
 	memorySize int
 	newQueue   Scheduler
 	readyQueue Scheduler
+	boosted    Processes // interactive processes back from IO, in arrival order
+	arriving   Processes // injected processes whose ArrivalStep hasn't come yet, in injection order
 	state      *DinoState
+	result     StepResult
+	step       int
+	noProgress int
+	events     []Event
+	metrics    Metrics
+	lastRun    string   // ID of the last process dispatched
+	switching  *Process // process the CPU is switching to
+	switchLeft int      // idle steps left before switching is dispatched
+	reason     string   // why the process about to be executed was picked, for the event log
+	progressed int      // processes that made progress during this step, up to MemoryBandwidth
+	terminated Processes
+	timeline   [][]rune
+	history    map[int][]byte // step -> snapshot of the Dino at the end of it
+	middleware []func(next StepFunc) StepFunc
+	poisson    bool    // when set, arrivals follow a Poisson process instead of keeping the new queue full
+	lambda     float64 // mean arrivals per step of the Poisson process
+	source     *randSource
+	rand       *rand.Rand
+	touches    *randSource // picks the cells TP_RANDOM touches, apart from rand so touching doesn't change the workload
+	touchRand  *rand.Rand
 }
 
 func New(totalMemory int) *Dino {
+	return NewSeeded(totalMemory, time.Now().UnixNano())
+}
+
+// NewSeeded creates a Dino whose random workload is fully determined by seed
+func NewSeeded(totalMemory int, seed int64) *Dino {
+	source := newRandSource(seed)
+	touches := newRandSource(touchSeed(seed))
 	new := &Dino{
-		memorySize: totalMemory,
-		Memory:     make(Memory, totalMemory),
-		newQueue:   &Queue{name: "New"},
-		readyQueue: &MultilevelQueue{name: "Ready Multilevel", queues: []Scheduler{&Queue{name: string(PT_INTERACTIVE)}, &Queue{name: string(PT_NONINTERACTIVE)}}},
-		state:      &DinoState{},
+		memorySize:     totalMemory,
+		Memory:         make(Memory, totalMemory),
+		StallThreshold: 10,
+		newQueue:       &Queue{name: "New"},
+		readyQueue:     newReadyQueue(),
+		state:          &DinoState{},
+		source:         source,
+		rand:           rand.New(source),
+		touches:        touches,
+		touchRand:      rand.New(touches),
 	}
 	return new
 }
 
+// touchSeed derives the seed of the touches from the seed of the Dino
+func touchSeed(seed int64) int64 {
+	return seed ^ 0x5deece66d
+}
+
+func newReadyQueue() Scheduler {
+	return &MultilevelQueue{name: "Ready Multilevel", queues: []Scheduler{&Queue{name: string(PT_INTERACTIVE)}, &Queue{name: string(PT_NONINTERACTIVE)}}}
+}
+
 type DinoState struct {
 	FreeMemory           int
 	Memory               MemoryLayout
@@ -35,7 +105,7 @@ type DinoState struct {
 	InteractiveQ         []string
 	ExtFragmentation     bool
 	ExecutedByCPU        *Process
-	ExecutedByIO         *Process
+	ExecutedByIO         Processes // processes that did IO during the last step
 	FragmentationProcess *Process
 	Message              string
 }
@@ -57,33 +127,200 @@ func (d *Dino) Run(max_epoch int) {
 
 		fmt.Printf("%s\n", state.String())
 		fmt.Printf("                                      %d                                      \n", i)
-		fmt.Println("--------------------------------------o--------------------------------------\n\n\n\n")
+		fmt.Print("--------------------------------------o--------------------------------------\n\n\n\n\n")
+	}
+	fmt.Println(d.Summary())
+}
+
+// RunUntil steps the Dino until pred holds or maxSteps have been taken, returning the last state and the steps taken
+func (d *Dino) RunUntil(pred func(*DinoState, *Dino) bool, maxSteps int) (*DinoState, int, error) {
+	state := d.state
+	steps := 0
+	for steps < maxSteps {
+		var err error
+		state, err = d.Step()
+		steps++
+		if err != nil {
+			return state, steps, err
+		} else if pred(state, d) {
+			break
+		}
+	}
+	return state, steps, nil
+}
+
+// RunRealtime performs a Step every interval, passing the new state to cb, until ctx is cancelled or a step fails.
+// Returns the error of the failed step, or nil when it was stopped by ctx
+func (d *Dino) RunRealtime(ctx context.Context, interval time.Duration, cb func(*DinoState)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	return d.runTicks(ctx, ticker.C, cb)
+}
+
+// runTicks performs RunRealtime, stepping once per value received from ticks
+func (d *Dino) runTicks(ctx context.Context, ticks <-chan time.Time, cb func(*DinoState)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticks:
+		}
+		// The tick and the cancellation can be ready at the same time, ctx wins
+		if ctx.Err() != nil {
+			return nil
+		}
+		state, err := d.Step()
+		if err != nil {
+			return err
+		}
+		if cb != nil {
+			cb(state)
+		}
+	}
+}
+
+// IsComplete reports whether every process has terminated: nothing is waiting to be admitted, ready or being switched to
+func (d *Dino) IsComplete() bool {
+	return len(d.processes()) == 0
+}
+
+// StepUntilIdle steps until IsComplete or maxSteps steps are taken, returning the last state and how many steps were
+// taken. Fails if no progress is made for StallThreshold steps, as the remaining processes may never run, unless
+// StallThreshold is 0 or less
+func (d *Dino) StepUntilIdle(maxSteps int) (*DinoState, int, error) {
+	state := d.state
+	steps := 0
+	for ; steps < maxSteps && !d.IsComplete(); steps++ {
+		var err error
+		if state, err = d.Step(); err != nil {
+			return state, steps + 1, err
+		} else if d.StallThreshold > 0 && d.Stalled(d.StallThreshold) {
+			return state, steps + 1, fmt.Errorf("Stalled -- no progress during the last %d steps", d.noProgress)
+		}
+	}
+	return state, steps, nil
+}
+
+// RunContext performs up to steps Steps, checking ctx before each of them, and returns a copy of the state after every
+// step. If ctx is cancelled, the states gathered so far are returned along with ctx.Err()
+func (d *Dino) RunContext(ctx context.Context, steps int) ([]*DinoState, error) {
+	states := []*DinoState{}
+	for i := 0; i < steps; i++ {
+		select {
+		case <-ctx.Done():
+			return states, ctx.Err()
+		default:
+		}
+		state, err := d.Step()
+		if err != nil {
+			return states, err
+		}
+		snapshot := *state
+		states = append(states, &snapshot)
 	}
+	return states, nil
 }
 
-func (d *Dino) Step() (state *DinoState, err error) {
+// StepResult is the state after a step, along with the processes each part of the simulator handled during it
+type StepResult struct {
+	State           *DinoState
+	Allocated       Processes
+	Released        Processes
+	DispatchedToCPU Processes
+	DispatchedToIO  Processes
+	Terminated      Processes
+	IdleReason      IdleReason // why the CPU ran nothing, "" if it ran a process
+}
+
+// IdleReason tells why the CPU was idle during a step
+type IdleReason string
+
+const (
+	ReasonQueueEmpty    = IdleReason("Queue empty")    // nothing was ready
+	ReasonAllBlocked    = IdleReason("All blocked")    // every ready process was waiting for space to grow
+	ReasonContextSwitch = IdleReason("Context switch") // the CPU was switching to another process
+	ReasonWaitingIO     = IdleReason("Waiting IO")     // the head of the ready queue was waiting for an IO device
+)
+
+// StepDetailed performs a Step, reporting what changed during it
+func (d *Dino) StepDetailed() (StepResult, error) {
+	state, err := d.Step()
+	d.result.State = state
+	return d.result, err
+}
+
+// StepFunc performs a step of the simulation
+type StepFunc func() (*DinoState, error)
+
+// Use wraps every following Step with middleware, which gets the rest of the chain as next and decides when (or
+// whether) to call it. Middlewares run in registration order, the first one registered being the outermost. They
+// aren't saved nor cloned
+func (d *Dino) Use(middleware func(next StepFunc) StepFunc) {
+	d.middleware = append(d.middleware, middleware)
+}
+
+// Step moves the simulation one step, through the registered middleware
+func (d *Dino) Step() (*DinoState, error) {
+	step := StepFunc(d.advance)
+	for i := len(d.middleware) - 1; i >= 0; i-- {
+		step = d.middleware[i](step)
+	}
+	return step()
+}
+
+// advance moves the simulation one step
+func (d *Dino) advance() (state *DinoState, err error) {
+	if _, ok := d.history[d.step]; d.KeepHistory && !ok {
+		if err := d.recordHistory(); err != nil {
+			return d.state, err
+		}
+	}
+	d.step++
+	d.result = StepResult{}
+	d.progressed = 0
 	d.state.Message = ""
 	d.state.ExtFragmentation = false
 
 	new := d.newQueue
 	ready := d.readyQueue
 
+	d.arriveInjected()
+	if d.poisson && !d.DisableArrivals {
+		for n := d.poissonArrivals(); n > 0; n-- {
+			d.arrive(d.RandomProcess())
+		}
+	}
+
+	progress := false
 	do := true
 	var newHasSpace bool
 	var memoryHasSpace bool
+	attempted := Processes{} // the head may be tried again after every arrival, but it's one attempt per step
 	for do || newHasSpace || memoryHasSpace {
 		do = false
-		newHasSpace = new.Len() < 10
+		newHasSpace = !d.DisableArrivals && !d.poisson && new.Len() < 10
 		if newHasSpace {
-			new.Add(d.RandomProcess())
+			d.arrive(d.RandomProcess())
 		}
 
-		p, _ := new.Read()
-		memoryHasSpace = d.Memory.HasSpace(p.SizeInKB)
+		p, err := new.Read()
+		if err != nil { // nothing is waiting to be admitted
+			break
+		}
+		memoryHasSpace = d.Memory.Reachable(p).HasSpace(p.SizeInKB)
+		if !attempted.contains(p) {
+			attempted = append(attempted, p)
+			d.metrics.AllocationAttempts++
+		}
 
 		if memoryHasSpace {
 			//Is when the 'dispatcher' takes an element from 'new' to 'ready'
-			err := d.Memory.AllocateWorstFit(p)
+			var err error
+			if _, ok := new.(*FragAwareScheduler); ok {
+				err = d.Memory.AllocateFit(p, FS_BEST_FIT) // into the hole p was picked for
+			} else {
+				err = d.Memory.AllocateWorstFit(p)
+			}
 			if err != nil {
 				panic(err.Error())
 			}
@@ -92,44 +329,244 @@ func (d *Dino) Step() (state *DinoState, err error) {
 				panic("Error while getting process from New queue")
 			}
 			ready.Add(p)
+			d.logEvent(ET_ALLOCATE, p, "")
+			if d.metrics.AdmittedSizes == nil {
+				d.metrics.AdmittedSizes = map[int]int{}
+			}
+			d.metrics.AdmittedSizes[p.SizeInKB]++
+			d.metrics.Allocations++
+			progress = true
 		} else if totalFree := d.Memory.TotalFree(); p.SizeInKB <= totalFree {
 			d.state.ExtFragmentation = true
 			d.state.FragmentationProcess = p
 		}
 	}
 
-	processReady, err := ready.Get()
-	if err != nil {
-		return nil, err
+	if d.switching != nil {
+		progress = true
 	}
+	d.state.ExecutedByIO = nil
+	dispatched := d.dispatchIO() // the IO bursts at the head, so the CPU can take the process behind them
+	if processReady := d.dispatch(); processReady != nil {
+		d.execute(processReady)
+		dispatched = append(dispatched, processReady)
+	}
+	dispatched = append(dispatched, d.dispatchIO()...)
 
-	if processReady.Bursts[0] == BT_CPU {
-		d.CPU(processReady)
-	} else if processReady.Bursts[0] == BT_IO {
-		d.IO(processReady)
+	for _, processReady := range dispatched {
+		progress = true
+		if processReady.ProgramCounter >= processReady.Lifespan() {
+			d.logEvent(ET_TERMINATE, processReady, "")
+			processReady.FinishStep = d.step
+			d.terminated = append(d.terminated, processReady)
+			d.logEvent(ET_RELEASE, processReady, "")
+			if d.Paging != nil {
+				d.Paging.Release(processReady)
+			}
+			deleted, err := d.Memory.ReleaseProcess(processReady)
+			if deleted && err == nil {
+				d.state.Message = fmt.Sprintf("Process %s released from memory", processReady.Name)
+			} else if err != nil {
+				d.state.Message = fmt.Sprintf("Problems releasing %s from memory", processReady.Name)
+				fmt.Printf("error: %s\n", err.Error())
+			}
+		} else {
+			d.requeue(processReady)
+		}
 	}
-	if processReady.ProgramCounter >= processReady.Lifespan() {
-		deleted, err := d.Memory.ReleaseProcess(processReady)
-		if deleted && err == nil {
-			d.state.Message = fmt.Sprintf("Process %s released from memory")
-		} else if err != nil {
-			d.state.Message = fmt.Sprintf("Problems releasing %s from memory")
-			fmt.Printf("error: %s\n", err.Error())
+
+	for _, p := range append(append(ready.Processes(), d.boosted...), d.switching) {
+		if p != nil && !dispatched.contains(p) {
+			p.WaitTime++
+			d.metrics.ReadyLengthSum++
 		}
-	} else {
-		ready.Add(processReady)
 	}
 
+	if progress {
+		d.noProgress = 0
+	} else if d.noProgress++; d.noProgress == d.StallThreshold {
+		d.logEvent(ET_STALL, nil, fmt.Sprintf("No progress during the last %d steps", d.noProgress))
+	}
+
+	d.stampProcesses()
+	d.recordMetrics()
+	if d.RecordTimeline {
+		d.timeline = append(d.timeline, d.Memory.Map())
+	}
+	d.refreshState()
+	if d.KeepHistory {
+		if err := d.recordHistory(); err != nil {
+			return d.state, err
+		}
+	}
+	return d.state, nil
+}
+
+// refreshState recomputes the parts of the state derived from memory and queues
+func (d *Dino) refreshState() {
 	d.state.FreeMemory = d.Memory.TotalFree()
 	d.state.Memory = d.Memory.Layout()
 	d.state.NewQ = d.newQueue.String()
 	d.state.InteractiveQ = d.readyQueue.String()
-	return d.state, nil
+}
+
+// dispatch returns the process to run during this step, charging the context switch when it's not the last one that
+// ran. Returns nil when the CPU stays idle, counting why
+func (d *Dino) dispatch() *Process {
+	if d.switching == nil {
+		p := d.nextRunnable()
+		if p == nil {
+			if head, err := d.readyQueue.Read(); err != nil {
+				d.metrics.IdleNothingReady++
+				d.result.IdleReason = ReasonQueueEmpty
+			} else if d.forIODevice(head) {
+				d.metrics.IdleWaitingIO++
+				d.result.IdleReason = ReasonWaitingIO
+			} else {
+				d.metrics.IdleBlocked++
+				d.result.IdleReason = ReasonAllBlocked
+			}
+			return nil
+		} else if d.ContextSwitchSteps == 0 || d.lastRun == "" || d.lastRun == p.ID {
+			d.lastRun = p.ID
+			return p
+		}
+		d.switching, d.switchLeft = p, d.ContextSwitchSteps
+		d.logEvent(ET_SWITCH, p, d.reason)
+	}
+
+	if d.switchLeft > 0 {
+		d.switchLeft--
+		d.metrics.IdleContextSwitch++
+		d.result.IdleReason = ReasonContextSwitch
+		return nil
+	}
+	p := d.switching
+	d.switching = nil
+	d.lastRun = p.ID
+	d.reason = "context switch done"
+	return p
+}
+
+// dispatchIO sends the ready processes at the head of the queue whose next burst is IO to the free IO devices
+// forIODevice reports whether p is left for the IO devices rather than run by the CPU
+func (d *Dino) forIODevice(p *Process) bool {
+	return !d.DisableIO && d.NumIODevices > 0 && p.Bursts[0] == BT_IO
+}
+
+func (d *Dino) dispatchIO() Processes {
+	dispatched := Processes{}
+	for !d.DisableIO && len(d.state.ExecutedByIO) < d.NumIODevices {
+		p, err := d.readyQueue.Read()
+		if err != nil || p.Bursts[0] != BT_IO || (len(p.Resizes) > 0 && p.Resizes[0].Step <= d.step) {
+			break
+		}
+		d.readyQueue.Get()
+		d.reason = "a free IO device; " + d.schedulerReason()
+		d.execute(p)
+		dispatched = append(dispatched, p)
+	}
+	return dispatched
+}
+
+// requeue puts a process that just ran back to wait for its next burst, ahead of everything else if it's boosted
+func (d *Dino) requeue(p *Process) {
+	if d.PrioritizeInteractive && p.Type == PT_INTERACTIVE && d.state.ExecutedByIO.contains(p) {
+		d.boosted = append(d.boosted, p)
+	} else {
+		d.readyQueue.Add(p)
+	}
+}
+
+// nextRunnable takes the next ready process that isn't blocked waiting for space to grow, applying its due resizes.
+// Blocked processes go back to the ready queue
+func (d *Dino) nextRunnable() *Process {
+	for len(d.boosted) > 0 {
+		p := d.boosted[0]
+		d.boosted = d.boosted[1:]
+		if d.resize(p) {
+			d.reason = "boosted back from IO"
+			return p
+		}
+		d.logEvent(ET_BLOCK, p, fmt.Sprintf("Waiting for space to grow to %d KB", p.Resizes[0].SizeInKB))
+		d.readyQueue.Add(p) // blocked processes lose their boost
+	}
+	for i := d.readyQueue.Len(); i > 0; i-- {
+		if p, err := d.readyQueue.Read(); err != nil || d.forIODevice(p) {
+			return nil // IO bursts are left for the IO devices
+		}
+		p, _ := d.readyQueue.Get()
+		if d.resize(p) {
+			d.reason = d.schedulerReason()
+			return p
+		}
+		d.logEvent(ET_BLOCK, p, fmt.Sprintf("Waiting for space to grow to %d KB", p.Resizes[0].SizeInKB))
+		d.readyQueue.Add(p)
+	}
+	return nil
+}
+
+// schedulerReason explains why the ready queue picked the process it just returned
+func (d *Dino) schedulerReason() string {
+	if r, ok := d.readyQueue.(Reasoner); ok {
+		return r.Reason()
+	}
+	return ""
+}
+
+// resize applies the resizes of p that are due, reporting false if p has to wait for memory to be freed
+func (d *Dino) resize(p *Process) bool {
+	for len(p.Resizes) > 0 && p.Resizes[0].Step <= d.step {
+		if err := d.Memory.Reallocate(p, p.Resizes[0].SizeInKB); err != nil {
+			return false
+		}
+		p.Resizes = p.Resizes[1:]
+		d.logEvent(ET_RESIZE, p, "")
+	}
+	return true
+}
+
+// execute runs the next burst of p on the CPU or the IO, depending on its type. Once the devices dispatched before
+// it used up the MemoryBandwidth, p holds its device for the step without running
+func (d *Dino) execute(p *Process) {
+	if d.MemoryBandwidth > 0 && d.progressed >= d.MemoryBandwidth {
+		d.reason = ""
+		d.metrics.BandwidthStalls++
+		if p.Bursts[0] == BT_CPU || d.DisableIO {
+			d.logEvent(ET_MEM_WAIT, p, "holding the CPU")
+			d.state.ExecutedByCPU = p
+		} else {
+			d.logEvent(ET_MEM_WAIT, p, "holding an IO device")
+			d.state.ExecutedByIO = append(d.state.ExecutedByIO, p)
+		}
+		return
+	}
+	d.progressed++
+	if d.Paging != nil {
+		d.Paging.touchPage(p)
+	}
+	d.touchCell(p)
+	if p.FirstRunStep == 0 {
+		p.FirstRunStep = d.step
+	}
+	reason := d.reason
+	d.reason = ""
+	if p.Bursts[0] == BT_CPU || d.DisableIO {
+		d.logEvent(ET_CPU, p, reason)
+		if d.metrics.CPUTime == nil {
+			d.metrics.CPUTime = map[string]int{}
+		}
+		d.metrics.CPUTime[p.ID]++
+		d.CPU(p)
+	} else if p.Bursts[0] == BT_IO {
+		d.logEvent(ET_IO, p, reason)
+		d.IO(p)
+	}
 }
 
 func (d *Dino) CPU(p *Process) {
 	p.ProgramCounter++
-	if p.Bursts[0] == BT_CPU && len(p.Bursts) > 1 {
+	if (p.Bursts[0] == BT_CPU || d.DisableIO) && len(p.Bursts) > 1 {
 		p.Bursts = p.Bursts[1 : len(p.Bursts)-1]
 	}
 	d.state.ExecutedByCPU = p
@@ -140,9 +577,266 @@ func (d *Dino) IO(p *Process) {
 	if p.Bursts[0] == BT_IO && len(p.Bursts) > 1 {
 		p.Bursts = p.Bursts[1 : len(p.Bursts)-1]
 	}
-	d.state.ExecutedByIO = p
+	d.state.ExecutedByIO = append(d.state.ExecutedByIO, p)
 }
 
 func (d *Dino) MemorySize() int {
 	return d.memorySize
 }
+
+// Inject adds a process to the New queue, to be admitted like any other arrival. A process with an ArrivalStep past the
+// next step is held until that step comes, so processes may be injected in any order
+func (d *Dino) Inject(p *Process) error {
+	if p == nil {
+		return errors.New("Cannot inject -- nil process")
+	} else if p.ID == "" {
+		return errors.New("Cannot inject -- please assign a (unique) ID to all your processes")
+	} else if p.SizeInKB < 1 {
+		return fmt.Errorf("Cannot inject -- invalid size %d", p.SizeInKB)
+	} else if p.Type != PT_INTERACTIVE && p.Type != PT_NONINTERACTIVE {
+		return fmt.Errorf("Cannot inject -- unknown process type '%s'", p.Type)
+	} else if err := p.ValidateBurstsWith(d.BurstRules); err != nil {
+		return fmt.Errorf("Cannot inject -- %s", err.Error())
+	} else if _, _, err := d.ProcessByID(p.ID); err == nil {
+		return fmt.Errorf("Cannot inject -- ID '%s' is already taken", p.ID)
+	}
+	if p.relativeResizes {
+		for i := range p.Resizes {
+			p.Resizes[i].Step += d.step
+		}
+		p.relativeResizes = false
+	}
+	p.step = d.step
+	if p.ArrivalStep == 0 {
+		p.ArrivalStep = d.step + 1 // it's admitted by the next step
+	} else if p.ArrivalStep > d.step+1 {
+		d.arriving = append(d.arriving, p)
+		return nil
+	}
+	return d.newQueue.Add(p)
+}
+
+// arriveInjected moves the injected processes whose ArrivalStep has come to the new queue, earliest first and in
+// injection order among equals, whatever order they were injected in
+func (d *Dino) arriveInjected() {
+	due := Processes{}
+	waiting := Processes{}
+	for _, p := range d.arriving {
+		if p.ArrivalStep <= d.step {
+			due = append(due, p)
+		} else {
+			waiting = append(waiting, p)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].ArrivalStep < due[j].ArrivalStep })
+	for _, p := range due {
+		d.newQueue.Add(p)
+	}
+	d.arriving = waiting
+}
+
+// SetArrivalRate switches random arrivals to a Poisson process with a mean of lambda arrivals per step, from the next
+// step on. A negative lambda goes back to keeping the new queue full
+func (d *Dino) SetArrivalRate(lambda float64) {
+	d.poisson = lambda >= 0
+	d.lambda = lambda
+}
+
+// poissonArrivals draws how many processes arrive this step
+func (d *Dino) poissonArrivals() int {
+	limit, n := math.Exp(-d.lambda), 0
+	for p := d.rand.Float64(); p > limit; p *= d.rand.Float64() {
+		n++
+	}
+	return n
+}
+
+// arrive adds a random process to the new queue
+func (d *Dino) arrive(p *Process) {
+	p.ArrivalStep = d.step
+	d.newQueue.Add(p)
+	d.metrics.Arrivals++
+}
+
+// SetScheduler replaces the ready queue scheduler between steps, moving the ready processes to s. Whatever the old
+// scheduler kept about them (quantum usage, feedback levels, virtual times) is dropped, so they start afresh in s
+func (d *Dino) SetScheduler(s Scheduler) error {
+	if s == nil {
+		return errors.New("Cannot set scheduler -- nil scheduler")
+	}
+	for _, p := range d.readyQueue.Processes() {
+		if err := s.Add(p); err != nil {
+			return err
+		}
+	}
+	d.readyQueue = s
+	d.refreshState()
+	return nil
+}
+
+// SetAdmissionScheduler replaces the scheduler of the processes waiting for memory, moving them to s
+func (d *Dino) SetAdmissionScheduler(s Scheduler) error {
+	if s == nil {
+		return errors.New("Cannot set admission scheduler -- nil scheduler")
+	}
+	for _, p := range d.newQueue.Processes() {
+		if err := s.Add(p); err != nil {
+			return err
+		}
+	}
+	d.newQueue = s
+	d.refreshState()
+	return nil
+}
+
+// MemoryTimeline returns the memory map at the end of every step run while RecordTimeline was set
+func (d *Dino) MemoryTimeline() [][]rune {
+	return d.timeline
+}
+
+// PlayTimeline writes the frames of a MemoryTimeline to w, one line each in order, waiting delay between them, so a
+// recorded run can be watched again
+func PlayTimeline(frames [][]rune, w io.Writer, delay time.Duration) error {
+	for i, frame := range frames {
+		if i > 0 && delay > 0 {
+			time.Sleep(delay)
+		}
+		if _, err := fmt.Fprintln(w, string(frame)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processes returns every process the Dino knows about: the boosted and ready ones, the one the CPU is switching to
+// and the ones waiting to be admitted
+func (d *Dino) processes() Processes {
+	all := append(Processes{}, d.boosted...)
+	all = append(all, d.readyQueue.Processes()...)
+	if d.switching != nil {
+		all = append(all, d.switching)
+	}
+	all = append(all, d.newQueue.Processes()...)
+	return append(all, d.arriving...)
+}
+
+// stampProcesses tells every process in the simulator the current step
+func (d *Dino) stampProcesses() {
+	for _, p := range d.processes() {
+		p.step = d.step
+	}
+}
+
+// ProcessesWithTag returns the processes in the simulator tagged with key=value
+func (d *Dino) ProcessesWithTag(key, value string) []*Process {
+	tagged := []*Process{}
+	for _, p := range d.processes() {
+		if tag, ok := p.Tags[key]; ok && tag == value {
+			tagged = append(tagged, p)
+		}
+	}
+	return tagged
+}
+
+// ProcessByID finds a process wherever it is, and tells where: "Terminated", "CPU" or "IO" when it ran during the last
+// step, "Switching", "Boosted", "Ready", "New", "Arriving" when it was injected to arrive at a later step, or "Memory"
+// when it's allocated but in none of the queues
+func (d *Dino) ProcessByID(id string) (*Process, string, error) {
+	find := func(ps Processes) *Process {
+		for _, p := range ps {
+			if p != nil && p.ID == id {
+				return p
+			}
+		}
+		return nil
+	}
+	locations := []struct {
+		name      string
+		processes Processes
+	}{
+		{"Terminated", d.terminated},
+		{"CPU", Processes{d.state.ExecutedByCPU}},
+		{"IO", d.state.ExecutedByIO},
+		{"Switching", Processes{d.switching}},
+		{"Boosted", d.boosted},
+		{"Ready", d.readyQueue.Processes()},
+		{"New", d.newQueue.Processes()},
+		{"Arriving", d.arriving},
+	}
+	for _, location := range locations {
+		if p := find(location.processes); p != nil {
+			return p, location.name, nil
+		}
+	}
+	for _, p := range d.Memory {
+		if p != nil && !isReservation(p) && p.ID == id {
+			return p, "Memory", nil
+		}
+	}
+	return nil, "", fmt.Errorf("Cannot find process -- no process has ID '%s'", id)
+}
+
+// TerminateProcess kills the process with the given ID wherever it is, freeing its memory and logging its termination
+// as killed. Terminated and pinned processes can't be killed, nor queued ones whose scheduler isn't a Remover
+func (d *Dino) TerminateProcess(id string) error {
+	p, location, err := d.ProcessByID(id)
+	if err != nil {
+		return fmt.Errorf("Cannot terminate -- no process has ID '%s'", id)
+	} else if location == "Terminated" {
+		return fmt.Errorf("Cannot terminate -- process '%s' already terminated", id)
+	} else if p.Pinned {
+		return fmt.Errorf("Cannot terminate -- process '%s' is pinned", id)
+	}
+
+	without := func(ps Processes) Processes {
+		kept := Processes{}
+		for _, q := range ps {
+			if q != p {
+				kept = append(kept, q)
+			}
+		}
+		return kept
+	}
+	for _, queue := range []Scheduler{d.readyQueue, d.newQueue} {
+		if !removeFrom(queue, p) {
+			return fmt.Errorf("Cannot terminate -- scheduler '%s' can't drop queued processes", queue.Name())
+		}
+	}
+	d.boosted = without(d.boosted)
+	d.arriving = without(d.arriving)
+	if d.switching == p {
+		d.switching, d.switchLeft = nil, 0
+	}
+	if d.state.ExecutedByCPU == p {
+		d.state.ExecutedByCPU = nil
+	}
+	if d.state.FragmentationProcess == p {
+		d.state.FragmentationProcess = nil
+	}
+	d.state.ExecutedByIO = without(d.state.ExecutedByIO)
+
+	d.logEvent(ET_TERMINATE, p, "killed")
+	p.FinishStep = d.step
+	d.terminated = append(d.terminated, p)
+	if p.IsAllocated {
+		d.logEvent(ET_RELEASE, p, "")
+		if d.Paging != nil {
+			d.Paging.Release(p)
+		}
+		if _, err := d.Memory.ReleaseProcess(p); err != nil {
+			return fmt.Errorf("Cannot terminate -- %s", err.Error())
+		}
+	}
+	d.refreshState()
+	return nil
+}
+
+// Stalled reports whether the last k steps neither admitted nor ran any process
+func (d *Dino) Stalled(k int) bool {
+	return d.noProgress >= k
+}
+
+// StepCount returns how many times Step has been called
+func (d *Dino) StepCount() int {
+	return d.step
+}
@@ -0,0 +1,878 @@
+package dino
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisableIO(t *testing.T) {
+	for _, devices := range []int{0, 2} {
+		d := NewSeeded(100, 1)
+		d.DisableArrivals = true
+		d.DisableIO = true
+		d.NumIODevices = devices
+
+		p := &Process{ID: "p", Name: "p", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_IO, BT_IO, BT_CPU, BT_IO}, MemoryAddress: -1}
+		q := &Process{ID: "q", Name: "q", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_IO, BT_IO, BT_CPU}, MemoryAddress: -1}
+		assert.NoError(t, d.Inject(p))
+		assert.NoError(t, d.Inject(q))
+		for i := 0; !d.IsComplete() && i < 20; i++ {
+			state, err := d.Step()
+			assert.NoError(t, err)
+			assert.Empty(t, state.ExecutedByIO, "IO is disabled, nothing should be executed by it")
+		}
+		assert.True(t, d.IsComplete(), "Processes should've run to completion")
+		assert.True(t, p.ProgramCounter >= p.Lifespan())
+		assert.True(t, q.ProgramCounter >= q.Lifespan())
+		for _, e := range d.Events() {
+			assert.NotEqual(t, ET_IO, e.Type, "%v", e)
+		}
+	}
+}
+
+// cloneDino clones d, failing the test if it can't
+func cloneDino(t *testing.T, d *Dino) *Dino {
+	clone, err := d.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clone
+}
+
+func assertSameDino(t *testing.T, expected, actual *Dino) {
+	assert.Equal(t, expected.StepCount(), actual.StepCount())
+	assert.Equal(t, len(expected.Memory), len(actual.Memory))
+	for i := range expected.Memory {
+		if expected.Memory[i] == nil {
+			assert.Nil(t, actual.Memory[i])
+		} else if assert.NotNil(t, actual.Memory[i]) {
+			assert.True(t, expected.Memory[i].Equal(actual.Memory[i]), "Cell %d differs: %v", i, expected.Memory[i].Diff(actual.Memory[i]))
+		}
+	}
+	assert.Equal(t, expected.Memory.Layout(), actual.Memory.Layout())
+	assert.Equal(t, expected.newQueue.String(), actual.newQueue.String())
+	assert.Equal(t, expected.readyQueue.String(), actual.readyQueue.String())
+	assert.True(t, expected.state.ExecutedByCPU.Equal(actual.state.ExecutedByCPU))
+	if assert.Equal(t, len(expected.state.ExecutedByIO), len(actual.state.ExecutedByIO)) {
+		for i := range expected.state.ExecutedByIO {
+			assert.True(t, expected.state.ExecutedByIO[i].Equal(actual.state.ExecutedByIO[i]))
+		}
+	}
+	assert.Equal(t, expected.Metrics(), actual.Metrics())
+}
+
+func TestSaveLoad(t *testing.T) {
+	uninterrupted := NewSeeded(200, 42)
+	interrupted := NewSeeded(200, 42)
+	for i := 0; i < 15; i++ {
+		_, err := uninterrupted.Step()
+		assert.NoError(t, err)
+		_, err = interrupted.Step()
+		assert.NoError(t, err)
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, interrupted.Save(&buf))
+	resumed, err := Load(&buf)
+	assert.NoError(t, err)
+	assertSameDino(t, interrupted, resumed)
+
+	// Memory cells must share the same process pointers as the queues
+	for _, p := range resumed.readyQueue.Processes() {
+		assert.True(t, resumed.Memory[p.MemoryAddress] == p)
+	}
+
+	for i := 0; i < 15; i++ {
+		_, err := uninterrupted.Step()
+		assert.NoError(t, err)
+		_, err = resumed.Step()
+		assert.NoError(t, err)
+	}
+	assertSameDino(t, uninterrupted, resumed)
+}
+
+// customPolicy is a replacement policy Load doesn't know, even if it's named like one it does
+type customPolicy struct{ LRUPolicy }
+
+func TestSaveUnsupported(t *testing.T) {
+	var buf bytes.Buffer
+	for _, s := range []Scheduler{NewWFQScheduler("WFQ"), NewMLFQScheduler("MLFQ"), NewShortestJobFirst("SJF", IDTieBreak)} {
+		d := NewSeeded(100, 1)
+		assert.NoError(t, d.SetScheduler(s))
+		assert.Error(t, d.Save(&buf), s.Name())
+		_, err := d.Clone()
+		assert.Error(t, err, "Cloning fails instead of panicking")
+	}
+	d := NewSeeded(100, 1)
+	d.SetScheduler(NewFragAwareScheduler("Frag", &d.Memory))
+	assert.Error(t, d.Save(&buf))
+
+	d = NewSeeded(100, 1)
+	d.Paging = NewPagedMemory(2)
+	d.Paging.Policy = customPolicy{}
+	assert.EqualError(t, d.Save(&buf), "Unknown replacement policy 'LRU'")
+	assert.Equal(t, 0, buf.Len(), "Nothing is written")
+	clone, err := d.Clone()
+	assert.Nil(t, clone)
+	assert.EqualError(t, err, "Cannot clone -- Unknown replacement policy 'LRU'")
+
+	d.Paging.Policy = LRUPolicy{}
+	assert.NoError(t, d.Save(&buf))
+	loaded, err := Load(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, LRUPolicy{}, loaded.Paging.Policy)
+}
+
+func TestIdleReason(t *testing.T) {
+	d := NewSeeded(20, 1)
+	d.DisableArrivals = true
+	result, _ := d.StepDetailed()
+	assert.Equal(t, ReasonQueueEmpty, result.IdleReason)
+
+	long := Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU}
+	grows := &Process{ID: "grows", Name: "grws", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: long, MemoryAddress: -1, Resizes: []Resize{{Step: 3, SizeInKB: 30}}}
+	d.Inject(grows)
+	result, _ = d.StepDetailed()
+	assert.Equal(t, IdleReason(""), result.IdleReason, "It ran")
+	result, _ = d.StepDetailed()
+	assert.Equal(t, ReasonAllBlocked, result.IdleReason, "It can't grow past the memory size")
+
+	d = NewSeeded(20, 1)
+	d.DisableArrivals = true
+	d.ContextSwitchSteps = 1
+	d.Inject(&Process{ID: "a", Name: "a", Type: PT_INTERACTIVE, SizeInKB: 5, Bursts: long, MemoryAddress: -1})
+	d.Inject(&Process{ID: "b", Name: "b", Type: PT_INTERACTIVE, SizeInKB: 5, Bursts: append(Bursts{}, long...), MemoryAddress: -1})
+	d.StepDetailed()
+	result, _ = d.StepDetailed()
+	assert.Equal(t, ReasonContextSwitch, result.IdleReason)
+	result, _ = d.StepDetailed()
+	assert.Equal(t, IdleReason(""), result.IdleReason, "b runs once switched to")
+}
+
+func TestStalled(t *testing.T) {
+	d := NewSeeded(50, 1)
+	d.DisableArrivals = true
+	d.StallThreshold = 5
+
+	small := &Process{ID: "small", Name: "smll", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1}
+	huge := &Process{ID: "huge", Name: "huge", Type: PT_INTERACTIVE, SizeInKB: 60, Bursts: Bursts{BT_CPU}, MemoryAddress: -1}
+	assert.NoError(t, d.Inject(small))
+	assert.NoError(t, d.Inject(huge))
+	assert.Error(t, d.Inject(nil))
+
+	_, err := d.Step()
+	assert.NoError(t, err)
+	assert.False(t, d.Stalled(1), "The small process was admitted and ran")
+
+	for i := 0; i < 10; i++ {
+		state, err := d.Step()
+		assert.NoError(t, err)
+		assert.NotNil(t, state)
+	}
+	assert.False(t, small.IsAllocated, "The small process should've finished")
+	assert.True(t, d.Stalled(5))
+	assert.False(t, d.Stalled(11))
+
+	stalls := 0
+	for _, e := range d.Events() {
+		if e.Type == ET_STALL {
+			stalls++
+		}
+	}
+	assert.Equal(t, 1, stalls, "A stall should be logged once per streak")
+}
+
+func TestStepDetailed(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+
+	a := &Process{ID: "a", Name: "a", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1}
+	b := &Process{ID: "b", Name: "b", Type: PT_INTERACTIVE, SizeInKB: 20, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1}
+	d.Inject(a)
+	result, err := d.StepDetailed()
+	assert.NoError(t, err)
+	assert.Equal(t, Processes{a}, result.Allocated)
+	assert.Equal(t, Processes{a}, result.DispatchedToCPU)
+	assert.Empty(t, result.Terminated)
+
+	d.Inject(b)
+	result, err = d.StepDetailed()
+	assert.NoError(t, err)
+	assert.Equal(t, d.state, result.State)
+	assert.Equal(t, Processes{b}, result.Allocated)
+	assert.Equal(t, Processes{a}, result.DispatchedToCPU)
+	assert.Empty(t, result.DispatchedToIO)
+	assert.Equal(t, Processes{a}, result.Terminated)
+	assert.Equal(t, Processes{a}, result.Released)
+	assert.False(t, a.IsAllocated)
+}
+
+func TestRunUntil(t *testing.T) {
+	d := NewSeeded(200, 7)
+	lowMemory := func(state *DinoState, d *Dino) bool {
+		return state.FreeMemory < 50
+	}
+
+	state, steps, err := d.RunUntil(lowMemory, 100)
+	assert.NoError(t, err)
+	assert.True(t, state.FreeMemory < 50)
+	assert.Equal(t, steps, d.StepCount())
+	assert.True(t, steps >= 1 && steps < 100)
+
+	never := func(state *DinoState, d *Dino) bool { return false }
+	_, steps, err = d.RunUntil(never, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, steps)
+}
+
+func TestClone(t *testing.T) {
+	d := NewSeeded(200, 3)
+	for i := 0; i < 10; i++ {
+		d.Step()
+	}
+	layout := d.Memory.Layout()
+	ready := d.readyQueue.String()
+	events := len(d.Events())
+
+	clone := cloneDino(t, d)
+	assertSameDino(t, d, clone)
+	for i := 0; i < 10; i++ {
+		_, err := clone.Step()
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, 10, d.StepCount())
+	assert.Equal(t, 20, clone.StepCount())
+	assert.Equal(t, layout, d.Memory.Layout())
+	assert.Equal(t, ready, d.readyQueue.String())
+	assert.Len(t, d.Events(), events)
+	for i := range d.Memory {
+		if d.Memory[i] != nil {
+			assert.True(t, d.Memory[i] != clone.Memory[i], "Clones should not share processes")
+		}
+	}
+
+	// Stepping the original now follows the same path the clone took
+	for i := 0; i < 10; i++ {
+		d.Step()
+	}
+	assertSameDino(t, clone, d)
+}
+
+func TestRunRealtime(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+	start := time.Now()
+	assert.NoError(t, New(100).RunRealtime(ctx, time.Millisecond, func(*DinoState) { calls++ }))
+	assert.True(t, time.Since(start) < time.Second)
+	assert.Equal(t, 0, calls, "A cancelled context should stop the loop before any step")
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	d := New(100)
+	start = time.Now()
+	assert.NoError(t, d.RunRealtime(ctx, time.Millisecond, func(*DinoState) { calls++ }))
+	assert.True(t, time.Since(start) < time.Second)
+	assert.Equal(t, calls, d.StepCount())
+
+	// One step per tick, with the ticks under the test's control
+	ctx, cancel = context.WithCancel(context.Background())
+	ticks, stepped, done := make(chan time.Time), make(chan bool), make(chan error)
+	d = New(100)
+	go func() { done <- d.runTicks(ctx, ticks, func(*DinoState) { stepped <- true }) }()
+	for i := 0; i < 5; i++ {
+		ticks <- time.Now()
+		<-stepped
+	}
+	cancel()
+	assert.NoError(t, <-done)
+	assert.Equal(t, 5, d.StepCount())
+}
+
+func TestResize(t *testing.T) {
+	d := NewSeeded(30, 1)
+	d.DisableArrivals = true
+
+	long := Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU}
+	b := &Process{ID: "b", Name: "b", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1}
+	a := &Process{ID: "a", Name: "a", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: long, MemoryAddress: -1, Resizes: []Resize{{Step: 1, SizeInKB: 15}}}
+	c := &Process{ID: "c", Name: "c", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: long, MemoryAddress: -1}
+	d.Inject(b)
+	d.Inject(a)
+	d.Inject(c)
+
+	// b runs, then a is blocked because memory is full and c runs in its place
+	d.Step()
+	result, err := d.StepDetailed()
+	assert.NoError(t, err)
+	assert.Equal(t, Processes{c}, result.DispatchedToCPU)
+	assert.Equal(t, 10, a.MemoryAddress)
+	assert.Equal(t, 10, a.SizeInKB)
+	assert.Equal(t, ET_BLOCK, d.Events()[len(d.Events())-2].Type)
+
+	// b terminates, freeing the space a needs to grow
+	result, _ = d.StepDetailed()
+	assert.Equal(t, Processes{b}, result.Terminated)
+
+	result, _ = d.StepDetailed()
+	assert.Equal(t, Processes{a}, result.DispatchedToCPU)
+	assert.Equal(t, 0, a.MemoryAddress, "a should have been relocated")
+	assert.Equal(t, 15, a.SizeInKB)
+	assert.Empty(t, a.Resizes)
+	for i := 0; i < 15; i++ {
+		assert.Equal(t, a, d.Memory[i])
+	}
+	assert.Nil(t, d.Memory[15])
+
+	replayed, err := Replay(d.Events(), d.MemorySize())
+	assert.NoError(t, err)
+	assert.Equal(t, d.Memory.Layout(), replayed.Memory.Layout())
+}
+
+func TestProcessesWithTag(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	acme := &Process{ID: "a", Name: "a", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1, Tags: map[string]string{"tenant": "acme"}}
+	initech := &Process{ID: "b", Name: "b", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1, Tags: map[string]string{"tenant": "initech"}}
+	untagged := &Process{ID: "c", Name: "c", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1}
+	d.Inject(acme)
+	d.Step()
+	d.Inject(initech)
+	d.Inject(untagged)
+
+	assert.Equal(t, []*Process{acme}, d.ProcessesWithTag("tenant", "acme"))
+	assert.Equal(t, []*Process{initech}, d.ProcessesWithTag("tenant", "initech"), "Processes waiting to be admitted count too")
+	assert.Empty(t, d.ProcessesWithTag("tenant", ""))
+	assert.Empty(t, d.ProcessesWithTag("team", "acme"))
+
+	clone := acme.Clone()
+	clone.Tags["tenant"] = "globex"
+	assert.Equal(t, "acme", acme.Tags["tenant"], "Clones should not share tags")
+	assert.Equal(t, []string{"Tags: map[tenant:acme] -> map[tenant:globex]"}, acme.Diff(clone))
+}
+
+func TestProcessByID(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	short := &Process{ID: "short", Name: "short", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1}
+	ready := &Process{ID: "ready", Name: "ready", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1}
+	d.Inject(short)
+	d.Inject(ready)
+	d.Step()
+	d.Step()
+	d.Step()
+	huge := &Process{ID: "huge", Name: "huge", Type: PT_INTERACTIVE, SizeInKB: 95, Bursts: Bursts{BT_CPU}, MemoryAddress: -1}
+	d.Inject(huge)
+	d.Memory.Allocate(&Process{ID: "loose", SizeInKB: 5}, 50)
+	d.Step()
+
+	locations := map[string]string{"short": "Terminated", "ready": "CPU", "huge": "New", "loose": "Memory"}
+	for id, location := range locations {
+		p, where, err := d.ProcessByID(id)
+		assert.NoError(t, err)
+		assert.Equal(t, id, p.ID)
+		assert.Equal(t, location, where, id)
+	}
+
+	// The other locations only last while the CPU switches or IO runs
+	io := &Process{ID: "io", Bursts: Bursts{BT_IO}}
+	switching := &Process{ID: "switching"}
+	boosted := &Process{ID: "boosted"}
+	queued := &Process{ID: "queued", Type: PT_INTERACTIVE}
+	d.state.ExecutedByIO = Processes{io}
+	d.switching = switching
+	d.boosted = Processes{boosted}
+	d.readyQueue.Add(queued)
+	for p, location := range map[*Process]string{io: "IO", switching: "Switching", boosted: "Boosted", queued: "Ready"} {
+		found, where, err := d.ProcessByID(p.ID)
+		assert.NoError(t, err)
+		assert.True(t, found == p)
+		assert.Equal(t, location, where)
+	}
+
+	_, _, err := d.ProcessByID("missing")
+	assert.Error(t, err)
+	_, _, err = d.ProcessByID(reserved.ID)
+	assert.Error(t, err)
+}
+
+func TestSetScheduler(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	assert.NoError(t, d.SetScheduler(NewQueue("FCFS")))
+
+	long := Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU}
+	a := &Process{ID: "a", Name: "a", Type: PT_NONINTERACTIVE, SizeInKB: 10, Bursts: long, MemoryAddress: -1}
+	b := &Process{ID: "b", Name: "b", Type: PT_NONINTERACTIVE, SizeInKB: 10, Bursts: append(Bursts{}, long...), MemoryAddress: -1}
+	c := &Process{ID: "c", Name: "c", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1}
+	d.Inject(a)
+	d.Inject(b)
+	d.Inject(c)
+	result, _ := d.StepDetailed()
+	assert.Equal(t, Processes{a}, result.DispatchedToCPU)
+
+	// FCFS would pick b now
+	assert.NoError(t, d.SetScheduler(NewShortestJobFirst("SJF", nil)))
+	assert.Equal(t, 3, d.readyQueue.Len())
+	for _, expected := range []*Process{c, c, a, a, a} {
+		result, _ = d.StepDetailed()
+		assert.Equal(t, Processes{expected}, result.DispatchedToCPU)
+	}
+
+	clone := cloneDino(t, d)
+	assert.Equal(t, "SJF", clone.readyQueue.Name())
+	d.Step()
+	clone.Step()
+	assertSameDino(t, d, clone)
+
+	assert.NoError(t, d.SetScheduler(NewMLFQScheduler("MLFQ", 1, 2)))
+	assert.Error(t, d.Save(&bytes.Buffer{}), "MLFQ levels can't be saved")
+	assert.Error(t, d.SetScheduler(nil))
+}
+
+func TestMemoryTimeline(t *testing.T) {
+	d := NewSeeded(10, 1)
+	d.DisableArrivals = true
+	d.Inject(&Process{ID: "a", Name: "a", Type: PT_INTERACTIVE, SizeInKB: 4, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1})
+	d.Step()
+	assert.Empty(t, d.MemoryTimeline(), "Recording is off by default")
+
+	d.RecordTimeline = true
+	d.Inject(&Process{ID: "b", Name: "b", Type: PT_INTERACTIVE, SizeInKB: 3, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1})
+	d.Memory.Reserve(9, 1)
+	for i := 0; i < 3; i++ {
+		d.Step()
+	}
+	expected := []string{
+		"----XXX--░", // b is admitted next to a, which terminates
+		"----XXX--░",
+		"---------░", // b terminates
+	}
+	timeline := []string{}
+	for _, frame := range d.MemoryTimeline() {
+		timeline = append(timeline, string(frame))
+	}
+	assert.Equal(t, expected, timeline)
+	assert.Equal(t, d.MemoryTimeline(), cloneDino(t, d).MemoryTimeline())
+
+	var played bytes.Buffer
+	start := time.Now()
+	assert.NoError(t, PlayTimeline(d.MemoryTimeline(), &played, time.Millisecond))
+	assert.True(t, time.Since(start) >= 2*time.Millisecond, "It waits between frames")
+	assert.Equal(t, strings.Join(expected, "\n")+"\n", played.String())
+}
+
+func TestStepUntilIdle(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	assert.True(t, d.IsComplete())
+	for _, id := range []string{"a", "b", "c"} {
+		d.Inject(&Process{ID: id, Name: id, Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1})
+	}
+	assert.False(t, d.IsComplete())
+	_, steps, err := d.StepUntilIdle(100)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, steps)
+	assert.True(t, d.IsComplete())
+	assert.Equal(t, 100, d.Memory.TotalFree())
+
+	d.Inject(&Process{ID: "huge", Name: "huge", Type: PT_INTERACTIVE, SizeInKB: 101, Bursts: Bursts{BT_CPU}, MemoryAddress: -1})
+	_, steps, err = d.StepUntilIdle(100)
+	assert.Error(t, err, "A process bigger than memory can never be admitted")
+	assert.Equal(t, d.StallThreshold, steps)
+
+	_, steps, err = d.StepUntilIdle(3)
+	assert.Error(t, err)
+	assert.Equal(t, 1, steps, "Still stalled")
+
+	d.StallThreshold = 0
+	_, steps, err = d.StepUntilIdle(5)
+	assert.NoError(t, err, "Stall detection is disabled")
+	assert.Equal(t, 5, steps)
+}
+
+func TestNumIODevices(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	d.NumIODevices = 2
+	io := Bursts{BT_IO, BT_IO, BT_IO, BT_IO, BT_IO, BT_IO, BT_IO, BT_IO, BT_IO, BT_IO}
+	a := &Process{ID: "a", Name: "a", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: append(Bursts{}, io...), MemoryAddress: -1}
+	b := &Process{ID: "b", Name: "b", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: append(Bursts{}, io...), MemoryAddress: -1}
+	c := &Process{ID: "c", Name: "c", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: append(Bursts{}, io...), MemoryAddress: -1}
+	d.Inject(a)
+	d.Inject(b)
+	d.Inject(c)
+
+	result, _ := d.StepDetailed()
+	assert.Equal(t, Processes{a, b}, result.DispatchedToIO)
+	assert.Equal(t, Processes{a, b}, d.state.ExecutedByIO)
+	assert.Equal(t, 0, c.ProgramCounter, "c waits for a free device")
+	assert.Equal(t, 1, c.WaitTime)
+
+	result, _ = d.StepDetailed()
+	assert.Equal(t, Processes{c, a}, result.DispatchedToIO)
+
+	replayed, err := Replay(d.Events(), d.MemorySize())
+	assert.NoError(t, err)
+	ids := func(ps Processes) []string {
+		ids := []string{}
+		for _, p := range ps {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"b", "c", "a"}, ids(d.readyQueue.Processes()))
+	assert.Equal(t, ids(d.readyQueue.Processes()), ids(replayed.readyQueue.Processes()))
+	assertSameDino(t, d, cloneDino(t, d))
+
+	// A CPU burst at the head of the queue goes to the CPU, and the devices keep serving the next IO bursts
+	d.NumIODevices = 1
+	cpu := &Process{ID: "cpu", Name: "cpu", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1}
+	d.Inject(cpu)
+	for i := 0; i < 2; i++ { // cpu is admitted behind a, while b and c do IO one at a time
+		result, _ = d.StepDetailed()
+		assert.Len(t, result.DispatchedToIO, 1)
+		assert.Equal(t, ReasonWaitingIO, result.IdleReason, "The CPU doesn't take the IO burst at the head")
+	}
+	result, _ = d.StepDetailed()
+	assert.Equal(t, Processes{cpu}, result.DispatchedToCPU)
+	assert.Equal(t, Processes{a}, result.DispatchedToIO)
+	assert.Equal(t, 4, d.Metrics().IdleWaitingIO, "Also while a and b took both devices")
+}
+
+func TestNumIODevicesMixedQueue(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	d.NumIODevices = 1
+	io := &Process{ID: "io", Name: "io", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_IO, BT_IO, BT_IO, BT_IO}, MemoryAddress: -1}
+	cpu := &Process{ID: "cpu", Name: "cpu", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1}
+	d.Inject(io)
+	d.Inject(cpu)
+
+	// The IO burst at the head goes to the device, and the CPU takes the process behind it
+	result, err := d.StepDetailed()
+	assert.NoError(t, err)
+	assert.Equal(t, Processes{io}, result.DispatchedToIO)
+	assert.Equal(t, Processes{cpu}, result.DispatchedToCPU)
+	assert.Equal(t, IdleReason(""), result.IdleReason)
+	assert.Equal(t, Processes{io}, d.state.ExecutedByIO)
+
+	_, _, err = d.StepUntilIdle(10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, io.FinishStep)
+	assert.Equal(t, 2, cpu.FinishStep)
+	assert.Equal(t, 0, d.CPUIdleSteps())
+}
+
+func TestMemoryBandwidth(t *testing.T) {
+	// Two IO devices stand for two processors, each running a process every step
+	run := func(bandwidth int) (a, b *Process, d *Dino) {
+		d = NewSeeded(100, 1)
+		d.DisableArrivals = true
+		d.NumIODevices = 2
+		d.MemoryBandwidth = bandwidth
+		io := Bursts{BT_IO, BT_IO, BT_IO, BT_IO, BT_IO, BT_IO, BT_IO, BT_IO, BT_IO, BT_IO}
+		a = &Process{ID: "a", Name: "a", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: append(Bursts{}, io...), MemoryAddress: -1}
+		b = &Process{ID: "b", Name: "b", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: append(Bursts{}, io...), MemoryAddress: -1}
+		d.Inject(a)
+		d.Inject(b)
+		_, _, err := d.StepUntilIdle(100)
+		assert.NoError(t, err)
+		return a, b, d
+	}
+
+	a, b, _ := run(0)
+	assert.Equal(t, 4, a.FinishStep)
+	assert.Equal(t, 4, b.FinishStep, "Both run side by side")
+
+	a, b, d := run(1)
+	assert.Equal(t, 4, a.FinishStep)
+	assert.Equal(t, 8, b.FinishStep, "b only progresses once a is done")
+	assert.Equal(t, 4, d.Metrics().BandwidthStalls)
+	assert.Equal(t, 4, b.FirstRunStep-b.ArrivalStep)
+	assertSameDino(t, d, cloneDino(t, d))
+	assert.Equal(t, 4, strings.Count(d.ScheduleTrace(), " b: "), "Only the steps b ran are traced")
+	waits := 0
+	for _, e := range d.Events() {
+		if e.Type == ET_MEM_WAIT {
+			waits++
+			assert.Equal(t, "b", e.ProcessID)
+		}
+	}
+	assert.Equal(t, 4, waits)
+
+	// The stalled process holds its device, but isn't reported as dispatched
+	d = NewSeeded(100, 1)
+	d.DisableArrivals = true
+	d.NumIODevices = 2
+	d.MemoryBandwidth = 1
+	a = &Process{ID: "a", Name: "a", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_IO, BT_IO, BT_IO, BT_IO}, MemoryAddress: -1}
+	b = &Process{ID: "b", Name: "b", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_IO, BT_IO, BT_IO, BT_IO}, MemoryAddress: -1}
+	d.Inject(a)
+	d.Inject(b)
+	result, err := d.StepDetailed()
+	assert.NoError(t, err)
+	assert.Equal(t, Processes{a}, result.DispatchedToIO)
+	assert.Equal(t, Processes{a, b}, d.state.ExecutedByIO)
+	assert.Equal(t, 0, b.ProgramCounter)
+
+	replayed, err := Replay(d.Events(), d.MemorySize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, replayed.readyQueue.Processes(), 2)
+	for i, p := range replayed.readyQueue.Processes() {
+		assert.Equal(t, d.readyQueue.Processes()[i].ID, p.ID)
+		assert.Equal(t, d.readyQueue.Processes()[i].ProgramCounter, p.ProgramCounter, "%s doesn't progress while it waits", p.ID)
+	}
+}
+
+func TestMemoryBandwidthCPUAndIO(t *testing.T) {
+	// The CPU and an IO device contend for the memory
+	run := func(bandwidth int) (cpu, io *Process, d *Dino) {
+		d = NewSeeded(100, 1)
+		d.DisableArrivals = true
+		d.NumIODevices = 1
+		d.MemoryBandwidth = bandwidth
+		cpu = &Process{ID: "cpu", Name: "cpu", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1}
+		io = &Process{ID: "io", Name: "io", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_IO, BT_IO, BT_IO, BT_IO}, MemoryAddress: -1}
+		d.Inject(cpu)
+		d.Inject(io)
+		_, _, err := d.StepUntilIdle(100)
+		assert.NoError(t, err)
+		return cpu, io, d
+	}
+
+	cpu, io, _ := run(0)
+	assert.Equal(t, 2, cpu.FinishStep)
+	assert.Equal(t, 2, io.FinishStep, "The CPU and the IO device run side by side")
+
+	cpu, io, d := run(1)
+	assert.Equal(t, 2, cpu.FinishStep, "The CPU is dispatched first")
+	assert.Equal(t, 4, io.FinishStep, "The IO process only progresses once the CPU is done")
+	assert.Equal(t, 2, d.Metrics().BandwidthStalls)
+}
+
+func TestPrioritizeInteractive(t *testing.T) {
+	for _, prioritize := range []bool{false, true} {
+		d := NewSeeded(100, 1)
+		d.DisableArrivals = true
+		d.PrioritizeInteractive = prioritize
+		d.SetScheduler(NewQueue("FCFS"))
+
+		ui := &Process{ID: "ui", Name: "ui", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_IO, BT_CPU, BT_CPU, BT_IO, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1}
+		d.Inject(ui)
+		for _, id := range []string{"hog1", "hog2"} {
+			d.Inject(&Process{ID: id, Name: id, Type: PT_NONINTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1})
+		}
+
+		result, _ := d.StepDetailed()
+		assert.Equal(t, Processes{ui}, result.DispatchedToIO)
+		result, _ = d.StepDetailed()
+		if prioritize {
+			assert.Equal(t, Processes{ui}, result.DispatchedToCPU, "Back from IO, ui should jump ahead of the hogs")
+			// After its CPU burst the boost is gone, so it waits behind both hogs
+			result, _ = d.StepDetailed()
+			assert.Equal(t, "hog1", result.DispatchedToCPU[0].ID)
+			assert.Equal(t, []string{"hog2", "ui", "hog1"}, []string{d.readyQueue.Processes()[0].ID, d.readyQueue.Processes()[1].ID, d.readyQueue.Processes()[2].ID})
+		} else {
+			assert.Equal(t, "hog1", result.DispatchedToCPU[0].ID)
+		}
+	}
+
+	d := NewSeeded(100, 1)
+	d.PrioritizeInteractive = true
+	d.DisableArrivals = true
+	d.Inject(&Process{ID: "ui", Name: "ui", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_IO, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1})
+	d.Step()
+	assert.Len(t, d.boosted, 1)
+	assertSameDino(t, d, cloneDino(t, d))
+	assert.Len(t, cloneDino(t, d).boosted, 1)
+}
+
+// cancelAfter is a context that gets cancelled once Done has been checked n times
+type cancelAfter struct {
+	context.Context
+	cancel context.CancelFunc
+	n      int
+}
+
+func (c *cancelAfter) Done() <-chan struct{} {
+	if c.n == 0 {
+		c.cancel()
+	}
+	c.n--
+	return c.Context.Done()
+}
+
+func TestRunContext(t *testing.T) {
+	d := NewSeeded(100, 1)
+	states, err := d.RunContext(context.Background(), 3)
+	assert.NoError(t, err)
+	assert.Len(t, states, 3)
+	assert.True(t, states[0] != states[1], "Every step should have its own copy of the state")
+	assert.Equal(t, 3, d.StepCount())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d = NewSeeded(100, 1)
+	states, err = d.RunContext(&cancelAfter{ctx, cancel, 4}, 10)
+	assert.Equal(t, context.Canceled, err)
+	assert.Len(t, states, 4)
+	assert.Equal(t, 4, d.StepCount())
+	assert.Equal(t, d.state.Memory, states[3].Memory)
+}
+
+func TestRewindTo(t *testing.T) {
+	d := NewSeeded(100, 3)
+	d.KeepHistory = true
+	assert.Error(t, d.RewindTo(0), "Nothing recorded yet")
+
+	var atTwo *Dino
+	for i := 0; i < 5; i++ {
+		_, err := d.Step()
+		assert.NoError(t, err)
+		if d.StepCount() == 2 {
+			atTwo = cloneDino(t, d)
+		}
+	}
+	assert.Error(t, d.RewindTo(6))
+
+	assert.NoError(t, d.RewindTo(2))
+	assertSameDino(t, atTwo, d)
+	assert.Error(t, d.RewindTo(4), "Steps after the rewound one are forgotten")
+
+	// Both carry on the same way
+	for i := 0; i < 3; i++ {
+		d.Step()
+		atTwo.Step()
+	}
+	assertSameDino(t, atTwo, d)
+
+	assert.NoError(t, d.RewindTo(0))
+	assertSameDino(t, NewSeeded(100, 3), d)
+}
+
+func TestSetArrivalRate(t *testing.T) {
+	d := NewSeeded(1000, 5)
+	d.SetArrivalRate(0)
+	for i := 0; i < 20; i++ {
+		d.Step()
+	}
+	assert.Equal(t, 0, d.Metrics().Arrivals)
+
+	d.SetArrivalRate(5)
+	for i := 0; i < 20; i++ {
+		d.Step()
+	}
+	assert.InDelta(t, 100, d.Metrics().Arrivals, 30)
+	assert.True(t, d.newQueue.Len() > 10, "The new queue isn't capped under a Poisson process")
+
+	// A saved Dino keeps the rate
+	clone := cloneDino(t, d)
+	d.Step()
+	clone.Step()
+	assertSameDino(t, d, clone)
+
+	arrivals := d.Metrics().Arrivals
+	d.SetArrivalRate(-1)
+	d.Step()
+	assert.Equal(t, arrivals, d.Metrics().Arrivals, "Back to keeping the new queue full, which is already")
+}
+
+func TestUse(t *testing.T) {
+	d := NewSeeded(100, 1)
+	calls := []string{}
+	seen := []*DinoState{}
+	trace := func(name string) func(next StepFunc) StepFunc {
+		return func(next StepFunc) StepFunc {
+			return func() (*DinoState, error) {
+				calls = append(calls, name+" before")
+				state, err := next()
+				seen = append(seen, state)
+				calls = append(calls, name+" after")
+				return state, err
+			}
+		}
+	}
+	d.Use(trace("outer"))
+	d.Use(trace("inner"))
+
+	state, err := d.Step()
+	assert.NoError(t, err)
+	assert.True(t, seen[0] == state && seen[1] == state, "State passes through unchanged")
+	assert.Equal(t, 1, d.StepCount())
+	assert.Equal(t, []string{"outer before", "inner before", "inner after", "outer after"}, calls)
+
+	// Middleware can skip the rest of the chain
+	d.Use(func(next StepFunc) StepFunc {
+		return func() (*DinoState, error) { return nil, errors.New("Paused") }
+	})
+	_, err = d.Step()
+	assert.Error(t, err)
+	assert.Equal(t, 1, d.StepCount())
+	assert.Len(t, calls, 8)
+}
+
+func TestOutOfOrderArrivals(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+
+	later := &Process{ID: "later", Name: "ltr", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1, ArrivalStep: 3}
+	earlier := &Process{ID: "earlier", Name: "erl", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1, ArrivalStep: 2}
+	assert.NoError(t, d.Inject(later))
+	assert.NoError(t, d.Inject(earlier))
+	_, location, err := d.ProcessByID("later")
+	assert.NoError(t, err)
+	assert.Equal(t, "Arriving", location)
+	assert.False(t, d.IsComplete(), "Processes yet to arrive aren't done")
+
+	for i := 0; i < 3; i++ {
+		_, err := d.Step()
+		assert.NoError(t, err)
+	}
+	allocated := map[string]int{}
+	for _, e := range d.Events() {
+		if e.Type == ET_ALLOCATE {
+			allocated[e.ProcessID] = e.Step
+		}
+	}
+	assert.Equal(t, map[string]int{"earlier": 2, "later": 3}, allocated)
+	assert.Equal(t, 2, earlier.ArrivalStep)
+	assert.Equal(t, 3, later.ArrivalStep)
+}
+
+func TestTerminateProcess(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	long := Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU}
+	a := &Process{ID: "a", Name: "a", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: long, MemoryAddress: -1}
+	b := &Process{ID: "b", Name: "b", Type: PT_INTERACTIVE, SizeInKB: 20, Bursts: append(Bursts{}, long...), MemoryAddress: -1}
+	d.Inject(a)
+	d.Inject(b)
+	d.Step()
+	_, location, _ := d.ProcessByID("a")
+	assert.Equal(t, "CPU", location)
+
+	assert.NoError(t, d.TerminateProcess("a"))
+	assert.Equal(t, 80, d.Memory.TotalFree(), "Only b is left in memory")
+	assert.Equal(t, Processes{b}, d.readyQueue.Processes())
+	assert.Nil(t, d.state.ExecutedByCPU)
+	_, location, _ = d.ProcessByID("a")
+	assert.Equal(t, "Terminated", location)
+	events := d.Events()
+	assert.Equal(t, Event{Step: 1, Type: ET_TERMINATE, ProcessID: "a", Name: "a", ProcessType: PT_INTERACTIVE, Start: 0, Size: 10, Detail: "killed"}, events[len(events)-2])
+	assert.Equal(t, ET_RELEASE, events[len(events)-1].Type)
+
+	assert.Error(t, d.TerminateProcess("a"), "Already terminated")
+	assert.Error(t, d.TerminateProcess("nobody"))
+	state, err := d.Step()
+	assert.NoError(t, err)
+	assert.Equal(t, b, state.ExecutedByCPU)
+}
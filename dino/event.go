@@ -0,0 +1,188 @@
+package dino
+
+import (
+	"fmt"
+	"strings"
+)
+
+type EventType string
+
+const (
+	// Event Types
+	ET_ALLOCATE  = EventType("Allocate")
+	ET_RELEASE   = EventType("Release")
+	ET_CPU       = EventType("CPU")
+	ET_IO        = EventType("IO")
+	ET_TERMINATE = EventType("Terminate")
+	ET_STALL     = EventType("Stall")
+	ET_RESIZE    = EventType("Resize")
+	ET_BLOCK     = EventType("Block")
+	ET_SWITCH    = EventType("Switch")
+	ET_MEM_WAIT  = EventType("MemWait") // dispatched, but couldn't run for lack of MemoryBandwidth
+)
+
+// Event is an entry of the Dino's event log
+type Event struct {
+	Step        int
+	Type        EventType
+	ProcessID   string
+	Name        string
+	ProcessType ProcessType
+	Start       int
+	Size        int
+	Detail      string
+}
+
+func (e Event) String() string {
+	if e.ProcessID == "" {
+		return fmt.Sprintf("[%4d] %-8s %s", e.Step, e.Type, e.Detail)
+	}
+	return fmt.Sprintf("[%4d] %-8s %s [%d, %d) %s", e.Step, e.Type, e.Name, e.Start, e.Start+e.Size, e.Detail)
+}
+
+// logEvent appends an event about p (which may be nil) to the event log
+func (d *Dino) logEvent(eventType EventType, p *Process, detail string) {
+	e := Event{Step: d.step, Type: eventType, Detail: detail}
+	if p != nil {
+		e.ProcessID = p.ID
+		e.Name = p.Name
+		e.ProcessType = p.Type
+		e.Start = p.MemoryAddress
+		e.Size = p.SizeInKB
+	}
+	d.events = append(d.events, e)
+
+	switch eventType {
+	case ET_ALLOCATE:
+		d.result.Allocated = append(d.result.Allocated, p)
+	case ET_RELEASE:
+		d.result.Released = append(d.result.Released, p)
+	case ET_CPU:
+		d.result.DispatchedToCPU = append(d.result.DispatchedToCPU, p)
+	case ET_IO:
+		d.result.DispatchedToIO = append(d.result.DispatchedToIO, p)
+	case ET_TERMINATE:
+		d.result.Terminated = append(d.result.Terminated, p)
+	}
+}
+
+// Events returns every event logged since the Dino was created
+func (d *Dino) Events() []Event {
+	return d.events
+}
+
+// ScheduleTrace returns the scheduling decisions taken so far, one per line: which process was dispatched or switched
+// to at every step, and why it was picked
+func (d *Dino) ScheduleTrace() string {
+	var b strings.Builder
+	for _, e := range d.events {
+		if e.Type == ET_CPU || e.Type == ET_IO || e.Type == ET_SWITCH {
+			reason := e.Detail
+			if reason == "" {
+				reason = "no reason given"
+			}
+			fmt.Fprintf(&b, "[%4d] %-8s %s: %s\n", e.Step, e.Type, e.Name, reason)
+		}
+	}
+	return b.String()
+}
+
+// Replay rebuilds the state of a Dino from its event log, by admitting, dispatching, resizing and releasing processes
+// in the same order they were. It assumes the default ready queue, without PrioritizeInteractive. Processes are rebuilt
+// from the events, so they only know their ID, name, type and size
+func Replay(events []Event, memorySize int) (*Dino, error) {
+	d := NewSeeded(memorySize, 0)
+	d.DisableArrivals = true
+	processes := map[string]*Process{}
+	running := Processes{} // processes dispatched during the step, that go back to the ready queue unless they terminate
+	var switching *Process
+
+	for _, e := range events {
+		if e.Step != d.step || (e.Type != ET_CPU && e.Type != ET_IO && e.Type != ET_MEM_WAIT && e.Type != ET_TERMINATE && e.Type != ET_RELEASE) {
+			for _, p := range running {
+				d.readyQueue.Add(p)
+			}
+			running = Processes{}
+			d.state.ExecutedByIO = nil
+		} else if e.Type == ET_TERMINATE {
+			for i, p := range running {
+				if p.ID == e.ProcessID {
+					running = append(running[:i:i], running[i+1:]...)
+					break
+				}
+			}
+		}
+		d.step = e.Step
+
+		switch e.Type {
+		case ET_ALLOCATE:
+			p := &Process{ID: e.ProcessID, Name: e.Name, Type: e.ProcessType, SizeInKB: e.Size, MemoryAddress: -1}
+			if err := d.Memory.Allocate(p, e.Start); err != nil {
+				return nil, fmt.Errorf("Cannot replay -- step %d: %s", e.Step, err.Error())
+			}
+			processes[p.ID] = p
+			d.readyQueue.Add(p)
+		case ET_SWITCH:
+			p, err := d.readyQueue.Get()
+			if err != nil || p.ID != e.ProcessID {
+				return nil, fmt.Errorf("Cannot replay -- step %d: process '%s' was not next in the ready queue", e.Step, e.ProcessID)
+			}
+			switching = p
+		case ET_CPU, ET_IO, ET_MEM_WAIT, ET_BLOCK:
+			p, err := switching, error(nil)
+			if p == nil || e.Type == ET_BLOCK {
+				p, err = d.readyQueue.Get()
+			} else {
+				switching = nil
+			}
+			if err != nil || p.ID != e.ProcessID {
+				return nil, fmt.Errorf("Cannot replay -- step %d: process '%s' was not next in the ready queue", e.Step, e.ProcessID)
+			}
+			if e.Type == ET_BLOCK {
+				d.readyQueue.Add(p)
+				break
+			}
+			running = append(running, p)
+			if e.Type == ET_MEM_WAIT {
+				break // it held its device without running
+			}
+			p.ProgramCounter++
+			if e.Type == ET_CPU {
+				d.state.ExecutedByCPU = p
+			} else {
+				d.state.ExecutedByIO = append(d.state.ExecutedByIO, p)
+			}
+		case ET_TERMINATE:
+			if p, ok := processes[e.ProcessID]; ok { // killed while waiting, rather than after its last burst
+				removeFrom(d.readyQueue, p)
+				if switching == p {
+					switching = nil
+				}
+			}
+		case ET_RESIZE:
+			p, ok := processes[e.ProcessID]
+			if !ok {
+				return nil, fmt.Errorf("Cannot replay -- step %d: unknown process '%s'", e.Step, e.ProcessID)
+			}
+			if err := d.Memory.Reallocate(p, e.Size); err != nil {
+				return nil, fmt.Errorf("Cannot replay -- step %d: %s", e.Step, err.Error())
+			}
+		case ET_RELEASE:
+			p, ok := processes[e.ProcessID]
+			if !ok {
+				return nil, fmt.Errorf("Cannot replay -- step %d: unknown process '%s'", e.Step, e.ProcessID)
+			}
+			if _, err := d.Memory.ReleaseProcess(p); err != nil {
+				return nil, fmt.Errorf("Cannot replay -- step %d: %s", e.Step, err.Error())
+			}
+			delete(processes, p.ID)
+		}
+		d.events = append(d.events, e)
+	}
+	for _, p := range running {
+		d.readyQueue.Add(p)
+	}
+
+	d.refreshState()
+	return d, nil
+}
@@ -0,0 +1,72 @@
+package dino
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplay(t *testing.T) {
+	d := NewSeeded(200, 11)
+	for i := 0; i < 40; i++ {
+		_, err := d.Step()
+		assert.NoError(t, err)
+	}
+
+	replayed, err := Replay(d.Events(), d.MemorySize())
+	assert.NoError(t, err)
+	assert.Equal(t, d.StepCount(), replayed.StepCount())
+	assert.Equal(t, d.Memory.Layout(), replayed.Memory.Layout())
+	for i := range d.Memory {
+		if d.Memory[i] == nil {
+			assert.Nil(t, replayed.Memory[i])
+		} else {
+			assert.Equal(t, d.Memory[i].ID, replayed.Memory[i].ID)
+		}
+	}
+
+	expectedReady := []string{}
+	for _, p := range d.readyQueue.Processes() {
+		expectedReady = append(expectedReady, p.ID)
+	}
+	replayedReady := []string{}
+	for _, p := range replayed.readyQueue.Processes() {
+		replayedReady = append(replayedReady, p.ID)
+	}
+	assert.Equal(t, expectedReady, replayedReady)
+	assert.Equal(t, d.state.ExecutedByCPU.ID, replayed.state.ExecutedByCPU.ID)
+
+	_, err = Replay(d.Events(), 10)
+	assert.Error(t, err, "Events don't fit in a smaller memory")
+}
+
+func TestReplayKilled(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	for _, id := range []string{"a", "b", "c", "big"} {
+		size := 30
+		if id == "big" {
+			size = 40 // waits for memory
+		}
+		long := Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU}
+		assert.NoError(t, d.Inject(&Process{ID: id, Name: id, Type: PT_INTERACTIVE, SizeInKB: size, Bursts: long, MemoryAddress: -1}))
+	}
+	d.Step()
+	_, location, _ := d.ProcessByID("b")
+	assert.Equal(t, "Ready", location)
+	assert.NoError(t, d.TerminateProcess("b"))
+	assert.NoError(t, d.TerminateProcess("big"))
+	d.Step()
+	assert.NoError(t, d.TerminateProcess("c")) // the one running
+	d.Step()
+
+	replayed, err := Replay(d.Events(), d.MemorySize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, d.Memory.Layout(), replayed.Memory.Layout())
+	assert.Equal(t, Processes{d.state.ExecutedByCPU}, d.readyQueue.Processes())
+	assert.Len(t, replayed.readyQueue.Processes(), 1)
+	assert.Equal(t, "a", replayed.readyQueue.Processes()[0].ID)
+	assert.Equal(t, d.state.ExecutedByCPU.ProgramCounter, replayed.readyQueue.Processes()[0].ProgramCounter)
+}
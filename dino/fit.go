@@ -0,0 +1,375 @@
+package dino
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+type FitStrategy string
+
+const (
+	// Fit Strategies
+	FS_FIRST_FIT      = FitStrategy("First Fit")
+	FS_BEST_FIT       = FitStrategy("Best Fit")
+	FS_WORST_FIT      = FitStrategy("Worst Fit")
+	FS_WORST_FIT_LAST = FitStrategy("Worst Fit (last)")
+	FS_BALANCED_FIT   = FitStrategy("Balanced Fit")
+)
+
+var ErrLeftoverTooSmall = errors.New("Cannot allocate -- every hole would leave a leftover smaller than the minimum")
+
+// holes returns the free blocks of memory, ordered by address
+func (m Memory) holes() []MemoryBlock {
+	holes := []MemoryBlock{}
+	for _, block := range m.Layout() {
+		if block.Name == FREE_BLOCK {
+			holes = append(holes, *block)
+		}
+	}
+	return holes
+}
+
+// HoleSelector picks one of the holes a process fits in, given ordered by address, and returns its index
+type HoleSelector func(holes []MemoryBlock) int
+
+// SelectFirst picks the lowest-addressed hole
+func SelectFirst(holes []MemoryBlock) int {
+	return 0
+}
+
+// SelectBest picks the smallest hole. Ties are broken by the lowest address
+func SelectBest(holes []MemoryBlock) int {
+	best := 0
+	for i, hole := range holes {
+		if hole.Size < holes[best].Size {
+			best = i
+		}
+	}
+	return best
+}
+
+// SelectWorst picks the largest hole. Ties are broken by the lowest address
+func SelectWorst(holes []MemoryBlock) int {
+	worst := 0
+	for i, hole := range holes {
+		if hole.Size > holes[worst].Size {
+			worst = i
+		}
+	}
+	return worst
+}
+
+// SelectLowFrag returns a selector that keeps the number of holes from growing when placing size: it picks a hole of
+// exactly size, which closes it, or else the smallest one leaving room for another process of the same size, or else
+// the largest one. Ties are broken by the lowest address
+func SelectLowFrag(size int) HoleSelector {
+	return func(holes []MemoryBlock) int {
+		usable := -1
+		for i, hole := range holes {
+			if hole.Size == size {
+				return i
+			} else if hole.Size-size >= size && (usable == -1 || hole.Size < holes[usable].Size) {
+				usable = i
+			}
+		}
+		if usable != -1 {
+			return usable
+		}
+		return SelectWorst(holes)
+	}
+}
+
+// AllocateLowFrag allocates p in the hole SelectLowFrag picks, so that HoleCount grows as little as possible
+func (m Memory) AllocateLowFrag(p *Process) error {
+	if p == nil {
+		return ErrNilProcess
+	}
+	return m.AllocateSelect(p, SelectLowFrag(p.SizeInKB))
+}
+
+// Select finds the hole for sizeToFit that sel picks among the ones it fits in
+func (m Memory) Select(sizeToFit int, sel HoleSelector) (start, offset int, err error) {
+	fitting := []MemoryBlock{}
+	for _, hole := range m.holes() {
+		if hole.Size >= sizeToFit {
+			fitting = append(fitting, hole)
+		}
+	}
+	if len(fitting) == 0 {
+		return -1, 0, ErrNoContiguousSpace
+	}
+	i := sel(fitting)
+	if i < 0 || i >= len(fitting) {
+		return -1, 0, fmt.Errorf("Cannot select hole -- picked %d out of %d holes", i, len(fitting))
+	}
+	return fitting[i].Start, fitting[i].Size, nil
+}
+
+// AllocateSelect allocates p in the hole sel picks among the ones it fits in
+func (m Memory) AllocateSelect(p *Process, sel HoleSelector) error {
+	if p == nil {
+		return ErrNilProcess
+	}
+	start, _, err := m.Reachable(p).Select(p.SizeInKB, sel)
+	if err != nil {
+		return err
+	}
+	return m.Allocate(p, start)
+}
+
+// FirstFit finds the lowest-addressed hole where sizeToFit fits
+func (m Memory) FirstFit(sizeToFit int) (start, offset int, err error) {
+	return m.Select(sizeToFit, SelectFirst)
+}
+
+// BestFit finds the smallest hole where sizeToFit fits. Ties are broken by the lowest address
+func (m Memory) BestFit(sizeToFit int) (start, offset int, err error) {
+	return m.Select(sizeToFit, SelectBest)
+}
+
+// BalancedFit finds the hole where sizeToFit leaves the remainder closest to the current mean hole size, so that
+// allocations create neither slivers nor oversized leftovers. Ties are broken by the lowest address
+func (m Memory) BalancedFit(sizeToFit int) (start, offset int, err error) {
+	mean := m.MeanHoleSize()
+	start, offset = -1, 0
+	bestDistance := 0.0
+	for _, hole := range m.holes() {
+		if hole.Size < sizeToFit {
+			continue
+		}
+		distance := math.Abs(float64(hole.Size-sizeToFit) - mean)
+		if start == -1 || distance < bestDistance {
+			start, offset, bestDistance = hole.Start, hole.Size, distance
+		}
+	}
+	if start == -1 {
+		err = ErrNoContiguousSpace
+	}
+	return start, offset, err
+}
+
+// Fit finds a hole for sizeToFit following the given strategy
+func (m Memory) Fit(sizeToFit int, strategy FitStrategy) (start, offset int, err error) {
+	find, err := m.finder(strategy)
+	if err != nil {
+		return -1, 0, err
+	}
+	return find(sizeToFit)
+}
+
+func (m Memory) finder(strategy FitStrategy) (func(sizeToFit int) (start, offset int, err error), error) {
+	switch strategy {
+	case FS_FIRST_FIT:
+		return m.FirstFit, nil
+	case FS_BEST_FIT:
+		return m.BestFit, nil
+	case FS_WORST_FIT:
+		return m.WorstFit, nil
+	case FS_WORST_FIT_LAST:
+		return m.WorstFitLast, nil
+	case FS_BALANCED_FIT:
+		return m.BalancedFit, nil
+	}
+	return nil, fmt.Errorf("Unknown fit strategy '%s'", strategy)
+}
+
+func (m Memory) AllocateFit(p *Process, strategy FitStrategy) (err error) {
+	if p == nil {
+		return ErrNilProcess
+	}
+	start, _, err := m.Reachable(p).Fit(p.SizeInKB, strategy)
+	if err != nil {
+		return err
+	}
+
+	return m.Allocate(p, start)
+}
+
+// AllocateAndLayout allocates p following strategy and returns the resulting layout. On failure memory is left
+// untouched and the layout is nil
+func (m Memory) AllocateAndLayout(p *Process, strategy FitStrategy) (MemoryLayout, error) {
+	if err := m.AllocateFit(p, strategy); err != nil {
+		return nil, err
+	}
+	return m.Layout(), nil
+}
+
+// AllocateBalanced allocates p in the hole chosen by BalancedFit
+func (m Memory) AllocateBalanced(p *Process) error {
+	return m.AllocateFit(p, FS_BALANCED_FIT)
+}
+
+// AllocateMinLeftover allocates p in the largest hole that it fills exactly or leaves at least minLeftover free cells
+// in, so that no unusable slivers are created
+func (m Memory) AllocateMinLeftover(p *Process, minLeftover int) error {
+	if p == nil {
+		return ErrNilProcess
+	}
+	start, offset, fits := -1, 0, false
+	for _, hole := range m.Reachable(p).holes() {
+		leftover := hole.Size - p.SizeInKB
+		if leftover < 0 {
+			continue
+		}
+		fits = true
+		if (leftover == 0 || leftover >= minLeftover) && hole.Size > offset {
+			start, offset = hole.Start, hole.Size
+		}
+	}
+	if !fits {
+		return ErrNoContiguousSpace
+	} else if start == -1 {
+		return ErrLeftoverTooSmall
+	}
+
+	return m.Allocate(p, start)
+}
+
+// AllocateOrCompact allocates p following strategy and, if it doesn't fit only because free memory is fragmented,
+// compacts memory and tries again. Reports whether memory was compacted
+func (m Memory) AllocateOrCompact(p *Process, strategy FitStrategy) (compacted bool, err error) {
+	err = m.AllocateFit(p, strategy)
+	if !errors.Is(err, ErrNoContiguousSpace) || p.SizeInKB > m.TotalFree() {
+		return false, err
+	}
+
+	if _, err = m.Compact(); err != nil {
+		return false, err
+	}
+	return true, m.AllocateFit(p, strategy)
+}
+
+// Placement asks for a process to be allocated at a fixed address
+type Placement struct {
+	Process *Process
+	Start   int
+}
+
+// Place allocates every process at the address of its placement. If any of them can't be placed, the ones already
+// placed are released and memory is left as it was
+func (m Memory) Place(placements []Placement) error {
+	for i, placement := range placements {
+		if err := m.Allocate(placement.Process, placement.Start); err != nil {
+			for _, placed := range placements[:i] {
+				m.ReleaseProcess(placed.Process)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// CanFitAll reports whether there's enough free memory for every process in ps, and the largest of them would fit
+// after compacting memory. It's a quick feasibility check: the processes aren't placed, and their order is ignored
+func (m Memory) CanFitAll(ps []*Process) bool {
+	total, largest := 0, 0
+	for _, p := range ps {
+		if p == nil {
+			return false
+		}
+		total += p.SizeInKB
+		if p.SizeInKB > largest {
+			largest = p.SizeInKB
+		}
+	}
+
+	// Compaction merges all the free cells between reserved and pinned blocks, which it can't move
+	largestCompacted, free := 0, 0
+	for i := range m {
+		if m[i] == nil {
+			free++
+		} else if m.isFixed(i) {
+			free = 0
+		}
+		if free > largestCompacted {
+			largestCompacted = free
+		}
+	}
+	return total <= m.TotalFree() && largest <= largestCompacted
+}
+
+// FillWorstFit allocates processes in order using worst fit, stopping at the first one that can't be allocated
+func (m Memory) FillWorstFit(ps []*Process) (placed []*Process) {
+	placed = []*Process{}
+	for _, p := range ps {
+		if m.AllocateWorstFit(p) != nil {
+			break
+		}
+		placed = append(placed, p)
+	}
+	return placed
+}
+
+// AllocateMinFrag allocates as many processes as possible, trying them in arrival, largest-first and smallest-first
+// order, and keeping the order that places the most processes with the lowest fragmentation ratio.
+func (m Memory) AllocateMinFrag(ps []*Process, strategy FitStrategy) (placed []*Process, err error) {
+	if _, err = m.finder(strategy); err != nil {
+		return nil, err
+	}
+	for _, p := range ps {
+		if p == nil {
+			return nil, ErrNilProcess
+		} else if p.IsAllocated {
+			return nil, ErrAlreadyAllocated
+		} else if p.ID == "" {
+			return nil, ErrMissingID
+		}
+	}
+
+	largestFirst := append([]*Process{}, ps...)
+	sort.SliceStable(largestFirst, func(i, j int) bool { return largestFirst[i].SizeInKB > largestFirst[j].SizeInKB })
+	smallestFirst := append([]*Process{}, ps...)
+	sort.SliceStable(smallestFirst, func(i, j int) bool { return smallestFirst[i].SizeInKB < smallestFirst[j].SizeInKB })
+
+	var best []*Process
+	bestPlaced, bestRatio := -1, 0.0
+	for _, order := range [][]*Process{ps, largestFirst, smallestFirst} {
+		trial := append(Memory{}, m...)
+		placedCount := 0
+		for _, p := range order {
+			if trial.AllocateFit(p.Clone(), strategy) == nil {
+				placedCount++
+			}
+		}
+		ratio := trial.FragmentationRatio()
+		if placedCount > bestPlaced || (placedCount == bestPlaced && ratio < bestRatio) {
+			best, bestPlaced, bestRatio = order, placedCount, ratio
+		}
+	}
+
+	placed = []*Process{}
+	for _, p := range best {
+		if m.AllocateFit(p, strategy) == nil {
+			placed = append(placed, p)
+		}
+	}
+	return placed, nil
+}
+
+// PackFFD is an offline packer: it sorts ps by decreasing size and allocates each with First Fit (first-fit-decreasing).
+// Processes that can't be allocated are returned as unplaced, in the same order
+func (m Memory) PackFFD(ps []*Process) (placed, unplaced []*Process) {
+	return m.packDecreasing(ps, FS_FIRST_FIT)
+}
+
+// PackBFD is like PackFFD but allocates each process with Best Fit (best-fit-decreasing), which keeps large holes for
+// the processes that need them
+func (m Memory) PackBFD(ps []*Process) (placed, unplaced []*Process) {
+	return m.packDecreasing(ps, FS_BEST_FIT)
+}
+
+func (m Memory) packDecreasing(ps []*Process, strategy FitStrategy) (placed, unplaced []*Process) {
+	largestFirst := append([]*Process{}, ps...)
+	sort.SliceStable(largestFirst, func(i, j int) bool { return largestFirst[i].SizeInKB > largestFirst[j].SizeInKB })
+	placed, unplaced = []*Process{}, []*Process{}
+	for _, p := range largestFirst {
+		if m.AllocateFit(p, strategy) == nil {
+			placed = append(placed, p)
+		} else {
+			unplaced = append(unplaced, p)
+		}
+	}
+	return placed, unplaced
+}
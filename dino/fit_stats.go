@@ -0,0 +1,83 @@
+package dino
+
+import (
+	"time"
+)
+
+// TimedMemory wraps a Memory, counting the fit searches made through it and the time spent in them
+type TimedMemory struct {
+	Memory
+	calls int
+	nanos int64
+}
+
+func NewTimedMemory(m Memory) *TimedMemory {
+	return &TimedMemory{Memory: m}
+}
+
+// FitSearchStats returns how many fit searches ran through tm since it was created or reset, and how long they took
+// in total
+func (tm *TimedMemory) FitSearchStats() (calls int, totalNanos int64) {
+	return tm.calls, tm.nanos
+}
+
+// ResetFitStats sets the counters of tm back to zero
+func (tm *TimedMemory) ResetFitStats() {
+	tm.calls, tm.nanos = 0, 0
+}
+
+// timeFitSearch returns a function that records a fit search started now. Use it as: defer tm.timeFitSearch()()
+func (tm *TimedMemory) timeFitSearch() func() {
+	start := time.Now()
+	return func() {
+		tm.calls++
+		tm.nanos += time.Since(start).Nanoseconds()
+	}
+}
+
+func (tm *TimedMemory) WorstFit(sizeToFit int) (start, offset int, err error) {
+	defer tm.timeFitSearch()()
+	return tm.Memory.WorstFit(sizeToFit)
+}
+
+func (tm *TimedMemory) WorstFitLast(sizeToFit int) (start, offset int, err error) {
+	defer tm.timeFitSearch()()
+	return tm.Memory.WorstFitLast(sizeToFit)
+}
+
+func (tm *TimedMemory) BalancedFit(sizeToFit int) (start, offset int, err error) {
+	defer tm.timeFitSearch()()
+	return tm.Memory.BalancedFit(sizeToFit)
+}
+
+func (tm *TimedMemory) Select(sizeToFit int, sel HoleSelector) (start, offset int, err error) {
+	defer tm.timeFitSearch()()
+	return tm.Memory.Select(sizeToFit, sel)
+}
+
+func (tm *TimedMemory) Fit(sizeToFit int, strategy FitStrategy) (start, offset int, err error) {
+	defer tm.timeFitSearch()()
+	return tm.Memory.Fit(sizeToFit, strategy)
+}
+
+func (tm *TimedMemory) HasSpace(size int) bool {
+	defer tm.timeFitSearch()()
+	return tm.Memory.HasSpace(size)
+}
+
+func (tm *TimedMemory) AllocateWorstFit(p *Process) error {
+	return tm.AllocateFit(p, FS_WORST_FIT)
+}
+
+func (tm *TimedMemory) AllocateFit(p *Process, strategy FitStrategy) error {
+	if p == nil {
+		return ErrNilProcess
+	}
+	done := tm.timeFitSearch()
+	start, _, err := tm.Reachable(p).Fit(p.SizeInKB, strategy)
+	done()
+	if err != nil {
+		return err
+	}
+	return tm.Memory.Allocate(p, start)
+}
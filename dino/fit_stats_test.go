@@ -0,0 +1,50 @@
+package dino
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFitSearchStats(t *testing.T) {
+	m := createTestMemory()
+	m.WorstFit(5)
+	tm := NewTimedMemory(m)
+	calls, nanos := tm.FitSearchStats()
+	assert.Equal(t, 0, calls, "Searches on the bare memory don't count")
+	assert.Equal(t, int64(0), nanos)
+
+	tm.WorstFit(5)
+	tm.Fit(5, FS_BEST_FIT)
+	assert.NoError(t, tm.AllocateFit(&Process{ID: "first", SizeInKB: 5}, FS_FIRST_FIT))
+	tm.HasSpace(1)
+	calls, nanos = tm.FitSearchStats()
+	assert.Equal(t, 4, calls)
+	assert.True(t, nanos > 0)
+
+	other := NewTimedMemory(createTestMemory())
+	other.WorstFit(5)
+	calls, _ = tm.FitSearchStats()
+	assert.Equal(t, 4, calls, "Other memories don't count")
+
+	tm.ResetFitStats()
+	calls, _ = tm.FitSearchStats()
+	assert.Equal(t, 0, calls)
+	tm.Memory.WorstFit(5)
+	calls, _ = tm.FitSearchStats()
+	assert.Equal(t, 0, calls)
+}
+
+func BenchmarkWorstFit(b *testing.B) {
+	tm := NewTimedMemory(fragmentedMemory(b))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tm.WorstFit(2)
+	}
+	b.StopTimer()
+	calls, nanos := tm.FitSearchStats()
+	if calls > 0 {
+		b.ReportMetric(float64(nanos)/float64(calls), "search-ns/op")
+	}
+}
@@ -0,0 +1,62 @@
+package dino
+
+import "errors"
+
+// FragAwareScheduler is an admission scheduler that picks, among the processes waiting for memory, the one that best
+// fills a hole (the one leaving the smallest leftover) instead of the oldest one. When nothing fits it keeps arrival
+// order. The Dino allocates the processes it picks with Best Fit, so they land in the hole they were picked for
+type FragAwareScheduler struct {
+	Queue
+	memory *Memory
+	read   *Process // the last process returned by Read, which Get must return even if memory changed in between
+}
+
+// NewFragAwareScheduler creates an admission scheduler that looks at the holes of memory, e.g. &d.Memory
+func NewFragAwareScheduler(name string, memory *Memory) *FragAwareScheduler {
+	return &FragAwareScheduler{Queue: Queue{name: name}, memory: memory}
+}
+
+// next returns the index of the process that leaves the smallest leftover, or the oldest one if none fits
+func (s *FragAwareScheduler) next() int {
+	best, bestLeftover := 0, -1
+	for i, p := range s.processes {
+		for _, hole := range s.memory.Reachable(p).holes() {
+			leftover := hole.Size - p.SizeInKB
+			if leftover >= 0 && (bestLeftover < 0 || leftover < bestLeftover) {
+				best, bestLeftover = i, leftover
+			}
+		}
+	}
+	return best
+}
+
+func (s *FragAwareScheduler) Get() (*Process, error) {
+	if len(s.processes) == 0 {
+		return nil, errors.New("Nothing to return")
+	}
+	i := -1
+	for j, p := range s.processes {
+		if p == s.read {
+			i = j
+		}
+	}
+	if i < 0 {
+		i = s.next()
+	}
+	p := s.processes[i]
+	s.processes = append(s.processes[:i:i], s.processes[i+1:]...)
+	s.read = nil
+	return p, nil
+}
+
+func (s *FragAwareScheduler) Reason() string {
+	return "fills a hole best, or came first if none fits"
+}
+
+func (s *FragAwareScheduler) Read() (*Process, error) {
+	if len(s.processes) == 0 {
+		return nil, errors.New("Nothing to return")
+	}
+	s.read = s.processes[s.next()]
+	return s.read, nil
+}
@@ -0,0 +1,61 @@
+package dino
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fragWorkload leaves two 20KB holes in memory, then offers two 15KB processes before two 20KB ones
+func fragWorkload(t *testing.T, admission func(d *Dino) Scheduler) *Dino {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	d.DisableIO = true
+	if admission != nil {
+		assert.NoError(t, d.SetAdmissionScheduler(admission(d)))
+	}
+	long := make(Bursts, 40)
+	for i := range long {
+		long[i] = BT_CPU
+	}
+	for i, id := range []string{"a", "b", "c", "d", "e"} {
+		bursts := long
+		if i%2 == 1 {
+			bursts = Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}
+		}
+		d.Inject(&Process{ID: id, Name: id, Type: PT_INTERACTIVE, SizeInKB: 20, Bursts: bursts, MemoryAddress: -1})
+	}
+	for i := 0; i < 20 && d.Memory.HoleCount() < 2; i++ {
+		d.Step()
+	}
+	assert.Equal(t, []int{20, 20}, holeSizes(d.Memory))
+
+	for i, size := range []int{15, 15, 20, 20} {
+		id := string(rune('f' + i))
+		d.Inject(&Process{ID: id, Name: id, Type: PT_INTERACTIVE, SizeInKB: size, Bursts: long, MemoryAddress: -1})
+	}
+	d.Step()
+	return d
+}
+
+func TestFragAwareScheduler(t *testing.T) {
+	fcfs := fragWorkload(t, nil)
+	assert.Equal(t, []int{5, 5}, holeSizes(fcfs.Memory), "FCFS admits both 15KB processes, leaving two 5KB holes")
+	assert.Equal(t, 0.5, fcfs.Memory.FragmentationRatio())
+
+	fragAware := fragWorkload(t, func(d *Dino) Scheduler { return NewFragAwareScheduler("New", &d.Memory) })
+	assert.Empty(t, holeSizes(fragAware.Memory), "The 20KB processes fill the holes exactly")
+	assert.Equal(t, 0.0, fragAware.Memory.FragmentationRatio())
+	assert.True(t, fragAware.Memory.FragmentationRatio() < fcfs.Memory.FragmentationRatio())
+
+	// The 15KB processes keep waiting, in arrival order
+	assert.Equal(t, 2, fragAware.newQueue.Len())
+	p, err := fragAware.newQueue.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, "f", p.ID)
+
+	// It survives a save and load
+	clone := cloneDino(t, fragAware)
+	_, ok := clone.newQueue.(*FragAwareScheduler)
+	assert.True(t, ok)
+}
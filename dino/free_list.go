@@ -0,0 +1,226 @@
+package dino
+
+import (
+	"fmt"
+	"math"
+)
+
+// FreeListMemory is a Memory that keeps track of its holes, so fit searches, allocations and releases take O(log n)
+// steps instead of scanning every cell. The holes are indexed by their start in a max tree, where every leaf holds the
+// size of the hole starting at that cell (0 elsewhere) and every inner node the largest size below it. Memory must
+// only be changed through the FreeListMemory, or the index gets out of sync
+type FreeListMemory struct {
+	Memory  Memory
+	tree    []int
+	leaves  int   // leaves of the tree, a power of two
+	startOf []int // for the last cell of every hole, where the hole starts
+	total   int   // free cells
+	holes   int
+}
+
+// NewFreeListMemory wraps m, which may already hold processes
+func NewFreeListMemory(m Memory) *FreeListMemory {
+	leaves := 1
+	for leaves < len(m) {
+		leaves *= 2
+	}
+	f := &FreeListMemory{Memory: m, tree: make([]int, 2*leaves), leaves: leaves, startOf: make([]int, len(m))}
+	for _, hole := range m.holes() {
+		f.addHole(hole.Start, hole.Size)
+	}
+	return f
+}
+
+// set stores the size of the hole starting at cell i, 0 if none does, and updates the maxima above it
+func (f *FreeListMemory) set(i, size int) {
+	node := f.leaves + i
+	f.tree[node] = size
+	for node > 1 {
+		node /= 2
+		f.tree[node] = f.tree[2*node]
+		if f.tree[2*node+1] > f.tree[node] {
+			f.tree[node] = f.tree[2*node+1]
+		}
+	}
+}
+
+func (f *FreeListMemory) addHole(start, size int) {
+	f.set(start, size)
+	f.startOf[start+size-1] = start
+	f.total += size
+	f.holes++
+}
+
+func (f *FreeListMemory) removeHole(start int) (size int) {
+	size = f.tree[f.leaves+start]
+	f.set(start, 0)
+	f.total -= size
+	f.holes--
+	return size
+}
+
+// first returns the lowest-addressed hole at or after from with at least size cells, or -1
+func (f *FreeListMemory) first(from, size int) int {
+	return f.search(1, 0, f.leaves, from, f.leaves, size, true)
+}
+
+// last returns the highest-addressed hole before to with at least size cells, or -1
+func (f *FreeListMemory) last(to, size int) int {
+	return f.search(1, 0, f.leaves, 0, to, size, false)
+}
+
+// search looks for a hole of at least size cells starting in [from, to), under node, which covers the cells [lo, hi)
+func (f *FreeListMemory) search(node, lo, hi, from, to, size int, lowest bool) int {
+	if hi <= from || to <= lo || f.tree[node] < size || size < 1 {
+		return -1
+	} else if node >= f.leaves {
+		return lo
+	}
+	mid := (lo + hi) / 2
+	children := [][3]int{{2 * node, lo, mid}, {2*node + 1, mid, hi}}
+	if !lowest {
+		children[0], children[1] = children[1], children[0]
+	}
+	for _, c := range children {
+		if i := f.search(c[0], c[1], c[2], from, to, size, lowest); i >= 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// Holes returns the free blocks, ordered by address
+func (f *FreeListMemory) Holes() []MemoryBlock {
+	holes := []MemoryBlock{}
+	for i := f.first(0, 1); i >= 0; i = f.first(i+1, 1) {
+		holes = append(holes, MemoryBlock{Start: i, Size: f.tree[f.leaves+i], Name: FREE_BLOCK})
+	}
+	return holes
+}
+
+func (f *FreeListMemory) TotalFree() int {
+	return f.total
+}
+
+func (f *FreeListMemory) HoleCount() int {
+	return f.holes
+}
+
+func (f *FreeListMemory) LargestFreeBlock() int {
+	return f.tree[1]
+}
+
+func (f *FreeListMemory) HasSpace(size int) bool {
+	return f.tree[1] >= size
+}
+
+func (f *FreeListMemory) Layout() MemoryLayout {
+	return f.Memory.Layout()
+}
+
+// Fit finds a hole for sizeToFit following the given strategy, with the same results as Memory.Fit. First and worst
+// fit take O(log n) steps, best and balanced fit go over the holes where sizeToFit fits
+func (f *FreeListMemory) Fit(sizeToFit int, strategy FitStrategy) (start, offset int, err error) {
+	if sizeToFit < 1 {
+		sizeToFit = 1
+	}
+	start = -1
+	switch strategy {
+	case FS_FIRST_FIT:
+		start = f.first(0, sizeToFit)
+	case FS_WORST_FIT:
+		start = f.first(0, f.tree[1])
+	case FS_WORST_FIT_LAST:
+		start = f.last(f.leaves, f.tree[1])
+	case FS_BEST_FIT, FS_BALANCED_FIT:
+		mean := float64(f.total) / float64(f.holes)
+		score := func(size int) float64 {
+			if strategy == FS_BEST_FIT {
+				return float64(size)
+			}
+			return math.Abs(float64(size-sizeToFit) - mean)
+		}
+		for i := f.first(0, sizeToFit); i >= 0; i = f.first(i+1, sizeToFit) {
+			if start == -1 || score(f.tree[f.leaves+i]) < score(f.tree[f.leaves+start]) {
+				start = i
+			}
+		}
+	default:
+		return -1, 0, fmt.Errorf("Unknown fit strategy '%s'", strategy)
+	}
+
+	if start == -1 || f.tree[f.leaves+start] < sizeToFit {
+		return -1, 0, ErrNoContiguousSpace
+	}
+	return start, f.tree[f.leaves+start], nil
+}
+
+// Allocate places p at start, which must be free, splitting the hole it was in
+func (f *FreeListMemory) Allocate(p *Process, start int) error {
+	if err := f.Memory.Allocate(p, start); err != nil {
+		return err
+	}
+	holeStart := f.last(start+1, 1)
+	holeEnd := holeStart + f.removeHole(holeStart)
+	if start > holeStart {
+		f.addHole(holeStart, start-holeStart)
+	}
+	if end := start + p.SizeInKB; end < holeEnd {
+		f.addHole(end, holeEnd-end)
+	}
+	return nil
+}
+
+func (f *FreeListMemory) AllocateFit(p *Process, strategy FitStrategy) error {
+	if p == nil {
+		return ErrNilProcess
+	}
+	var start int
+	var err error
+	if p.MaxAddress > 0 { // the index covers the whole memory, so the reachable part is searched cell by cell
+		start, _, err = f.Memory.Reachable(p).Fit(p.SizeInKB, strategy)
+	} else {
+		start, _, err = f.Fit(p.SizeInKB, strategy)
+	}
+	if err != nil {
+		return err
+	}
+	return f.Allocate(p, start)
+}
+
+func (f *FreeListMemory) AllocateWorstFit(p *Process) error {
+	return f.AllocateFit(p, FS_WORST_FIT)
+}
+
+// ReleaseProcess frees the cells of p, merging them with the holes around. Each fragment of a scattered process is
+// freed as a hole of its own
+func (f *FreeListMemory) ReleaseProcess(p *Process) (bool, error) {
+	if p == nil {
+		return false, ErrNilProcess
+	}
+	blocks := append([]MemoryBlock(nil), p.Fragments...)
+	if len(blocks) == 0 {
+		blocks = []MemoryBlock{{Start: p.MemoryAddress, Size: p.SizeInKB}}
+	}
+	released, err := f.Memory.ReleaseProcess(p)
+	if err != nil || !released {
+		return released, err
+	}
+
+	for _, block := range blocks {
+		f.free(block.Start, block.Start+block.Size)
+	}
+	return true, nil
+}
+
+// free indexes the just released cells [start, end) as a hole, merging it with the holes around
+func (f *FreeListMemory) free(start, end int) {
+	if start > 0 && f.Memory[start-1] == nil {
+		start = f.startOf[start-1]
+		f.removeHole(start)
+	}
+	if end < len(f.Memory) && f.Memory[end] == nil {
+		end += f.removeHole(end)
+	}
+	f.addHole(start, end-start)
+}
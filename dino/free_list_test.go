@@ -0,0 +1,98 @@
+package dino
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreeListMemory(t *testing.T) {
+	strategies := []FitStrategy{FS_FIRST_FIT, FS_BEST_FIT, FS_WORST_FIT, FS_WORST_FIT_LAST, FS_BALANCED_FIT}
+	for seed := int64(1); seed <= 5; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		slice := make(Memory, 200)
+		assert.NoError(t, slice.Reserve(100, 5))
+		list := NewFreeListMemory(append(Memory{}, slice...))
+
+		var inSlice, inList []*Process
+		for i := 0; i < 500; i++ {
+			if len(inSlice) > 0 && r.Intn(3) == 0 {
+				j := r.Intn(len(inSlice))
+				_, errSlice := slice.ReleaseProcess(inSlice[j])
+				_, errList := list.ReleaseProcess(inList[j])
+				assert.Equal(t, errSlice, errList)
+				inSlice = append(inSlice[:j], inSlice[j+1:]...)
+				inList = append(inList[:j], inList[j+1:]...)
+			} else {
+				p := &Process{ID: fmt.Sprint(i), Name: fmt.Sprint(i), SizeInKB: 1 + r.Intn(30)}
+				q := p.Clone()
+				strategy := strategies[r.Intn(len(strategies))]
+				errSlice := slice.AllocateFit(p, strategy)
+				errList := list.AllocateFit(q, strategy)
+				if assert.Equal(t, errSlice, errList, "seed %d, step %d, %s", seed, i, strategy) && errSlice == nil {
+					inSlice = append(inSlice, p)
+					inList = append(inList, q)
+				}
+			}
+
+			if !assert.Equal(t, slice.Layout(), list.Layout(), "seed %d, step %d", seed, i) {
+				return
+			}
+			assert.Equal(t, slice.holes(), list.Holes())
+			assert.Equal(t, slice.TotalFree(), list.TotalFree())
+			assert.Equal(t, slice.LargestFreeBlock(), list.LargestFreeBlock())
+			assert.Equal(t, slice.HoleCount(), list.HoleCount())
+		}
+	}
+}
+
+func TestFreeListMemoryErrors(t *testing.T) {
+	list := NewFreeListMemory(createAllocatedTestMemory())
+	assert.Equal(t, 28, list.TotalFree())
+	assert.True(t, errors.Is(list.Allocate(&Process{ID: "p", SizeInKB: 3}, 0), ErrSpaceOccupied))
+	assert.True(t, errors.Is(list.AllocateWorstFit(&Process{ID: "p", SizeInKB: 10}), ErrNoContiguousSpace))
+	assert.True(t, errors.Is(list.AllocateWorstFit(nil), ErrNilProcess))
+	assert.True(t, errors.Is(list.Allocate(&Process{ID: "empty", SizeInKB: 0}, 12), ErrInvalidSize))
+	assert.True(t, errors.Is(list.Allocate(&Process{ID: "negative", SizeInKB: -2}, 12), ErrInvalidSize))
+	assert.True(t, errors.Is(make(Memory, 10).Allocate(&Process{ID: "empty"}, 0), ErrInvalidSize))
+	released, err := list.ReleaseProcess(nil)
+	assert.False(t, released)
+	assert.True(t, errors.Is(err, ErrNilProcess))
+	_, _, err = list.Fit(1, FitStrategy("Random Fit"))
+	assert.Error(t, err)
+	assert.Equal(t, 28, list.TotalFree(), "Failed allocations don't change the list")
+	assert.False(t, list.HasSpace(10))
+	assert.True(t, list.HasSpace(9))
+}
+
+// fragmentedMemory allocates one cell out of every three, leaving 2-cell holes all over
+func fragmentedMemory(b *testing.B) Memory {
+	m := make(Memory, 1<<16)
+	for i := 0; i+3 <= len(m); i += 3 {
+		if err := m.Allocate(&Process{ID: "wall", SizeInKB: 1}, i+2); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return m
+}
+
+func BenchmarkFreeListWorstFit(b *testing.B) {
+	list := NewFreeListMemory(fragmentedMemory(b))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		list.Fit(2, FS_WORST_FIT)
+	}
+}
+
+func BenchmarkFreeListAllocateRelease(b *testing.B) {
+	list := NewFreeListMemory(fragmentedMemory(b))
+	p := &Process{ID: "p", SizeInKB: 2}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		list.Allocate(p, 3*(i%(1<<14)))
+		list.ReleaseProcess(p)
+	}
+}
@@ -1,7 +1,10 @@
 package dino
 
 import (
+	"encoding/binary"
 	"math/rand"
+
+	"github.com/nu7hatch/gouuid"
 )
 
 const ()
@@ -15,8 +18,8 @@ func abbrev(s string) string {
 	}
 }
 
-func randomType() ProcessType {
-	dice := rand.Int() % 2
+func randomType(r *rand.Rand) ProcessType {
+	dice := r.Int() % 2
 	if dice == 0 {
 		return PT_NONINTERACTIVE
 	} else {
@@ -24,22 +27,22 @@ func randomType() ProcessType {
 	}
 }
 
-func randomBursts(processType ProcessType, minLifespan, maxLifespan int) Bursts {
-	lifespan := randomInteger(minLifespan, maxLifespan)
+func randomBursts(r *rand.Rand, processType ProcessType, minLifespan, maxLifespan int) Bursts {
+	lifespan := randomInteger(r, minLifespan, maxLifespan)
 	cpu := 1
 	io := 1
 	if processType == PT_INTERACTIVE { // cpu/io -- min: 0.5   max: 0.8
-		cpu = randomInteger(50, 80)
+		cpu = randomInteger(r, 50, 80)
 		io = 100
 	} else if processType == PT_NONINTERACTIVE { // cpu/io -- min: 0.8   max: 1
-		cpu = randomInteger(80, 100)
+		cpu = randomInteger(r, 80, 100)
 		io = 100
 	}
 	cpuBoundingCoefficient := float64(cpu) / float64(io)
 
 	bursts := make(Bursts, lifespan)
 	for i := 0; i < lifespan; i++ {
-		roulette := rand.Float64()
+		roulette := r.Float64()
 		rouletteSaysCpu := roulette < cpuBoundingCoefficient
 		if rouletteSaysCpu {
 			bursts[i] = BT_CPU
@@ -50,8 +53,48 @@ func randomBursts(processType ProcessType, minLifespan, maxLifespan int) Bursts
 	return bursts
 }
 
-func randomInteger(min, max int) int {
-	randInt := rand.Int()%(max-min) + min
+func randomInteger(r *rand.Rand, min, max int) int {
+	randInt := r.Int()%(max-min) + min
 	//	fmt.Println("Random: ", randInt)
 	return randInt
 }
+
+// randomUUID builds a version 4 UUID out of r, so process IDs are reproducible for a given seed
+func randomUUID(r *rand.Rand) string {
+	var u uuid.UUID
+	binary.BigEndian.PutUint64(u[0:8], r.Uint64())
+	binary.BigEndian.PutUint64(u[8:16], r.Uint64())
+	u[6] = (u[6] & 0x0f) | 0x40 // version 4
+	u[8] = (u[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return u.String()
+}
+
+// randSource is a rand.Source that counts its draws, so its state can be saved and restored
+type randSource struct {
+	seed  int64
+	draws uint64
+	src   rand.Source
+}
+
+func newRandSource(seed int64) *randSource {
+	return &randSource{seed: seed, src: rand.NewSource(seed)}
+}
+
+func (s *randSource) Int63() int64 {
+	s.draws++
+	return s.src.Int63()
+}
+
+func (s *randSource) Seed(seed int64) {
+	s.seed = seed
+	s.draws = 0
+	s.src.Seed(seed)
+}
+
+// restore brings the source back to the state it had after the given number of draws
+func (s *randSource) restore(seed int64, draws uint64) {
+	s.Seed(seed)
+	for s.draws < draws {
+		s.Int63()
+	}
+}
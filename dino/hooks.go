@@ -0,0 +1,78 @@
+package dino
+
+// HookedMemory wraps a Memory, calling the registered hooks every time a process is allocated or released through it.
+// The Memory isn't embedded, so none of its other ways of placing or freeing cells can bypass the hooks
+type HookedMemory struct {
+	memory     Memory
+	onAllocate []func(p *Process, start int)
+	onRelease  []func(p *Process, start int)
+}
+
+func NewHookedMemory(m Memory) *HookedMemory {
+	return &HookedMemory{memory: m}
+}
+
+func (hm *HookedMemory) Layout() MemoryLayout {
+	return hm.memory.Layout()
+}
+
+func (hm *HookedMemory) TotalFree() int {
+	return hm.memory.TotalFree()
+}
+
+func (hm *HookedMemory) HasSpace(size int) bool {
+	return hm.memory.HasSpace(size)
+}
+
+func (hm *HookedMemory) Fit(sizeToFit int, strategy FitStrategy) (start, offset int, err error) {
+	return hm.memory.Fit(sizeToFit, strategy)
+}
+
+// OnAllocate registers fn to be called after every successful allocation
+func (hm *HookedMemory) OnAllocate(fn func(p *Process, start int)) {
+	hm.onAllocate = append(hm.onAllocate, fn)
+}
+
+// OnRelease registers fn to be called after every successful release, with the address p was released from
+func (hm *HookedMemory) OnRelease(fn func(p *Process, start int)) {
+	hm.onRelease = append(hm.onRelease, fn)
+}
+
+func (hm *HookedMemory) Allocate(p *Process, start int) error {
+	if err := hm.memory.Allocate(p, start); err != nil {
+		return err
+	}
+	for _, fn := range hm.onAllocate {
+		fn(p, start)
+	}
+	return nil
+}
+
+func (hm *HookedMemory) AllocateWorstFit(p *Process) error {
+	return hm.AllocateFit(p, FS_WORST_FIT)
+}
+
+func (hm *HookedMemory) AllocateFit(p *Process, strategy FitStrategy) error {
+	if p == nil {
+		return ErrNilProcess
+	}
+	start, _, err := hm.memory.Reachable(p).Fit(p.SizeInKB, strategy)
+	if err != nil {
+		return err
+	}
+	return hm.Allocate(p, start)
+}
+
+func (hm *HookedMemory) ReleaseProcess(p *Process) (bool, error) {
+	if p == nil {
+		return false, ErrNilProcess
+	}
+	start := p.MemoryAddress
+	released, err := hm.memory.ReleaseProcess(p)
+	if released && err == nil {
+		for _, fn := range hm.onRelease {
+			fn(p, start)
+		}
+	}
+	return released, err
+}
@@ -0,0 +1,149 @@
+package dino
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// TEXT_MEMORY_SIZE is the memory, in KB, of the Dino the text commands start with
+const TEXT_MEMORY_SIZE = 200
+
+// RunInteractiveText drives a Dino with the commands read from r, one per line, writing the state to w after each
+// of them. It's a scriptable alternative to the terminal UI. The Dino starts with TEXT_MEMORY_SIZE KB and no random
+// arrivals. Commands are:
+//
+//	memory SIZE [SEED]          starts over with a Dino of SIZE KB, whose random bursts come from SEED (0 by default)
+//	step                        runs a step
+//	stepback                    goes back to the previous step
+//	inject NAME SIZE [BURSTS]   injects an interactive process of SIZE KB, with BURSTS CPU bursts or random ones
+//	compact                     compacts memory
+//	quit                        stops reading
+//
+// Commands that fail are reported in place of the step message, without stopping. The Dino is saved around every
+// step, so it can be stepped back
+func RunInteractiveText(r io.Reader, w io.Writer) error {
+	_, err := newTextDino(TEXT_MEMORY_SIZE, 0).runText(r, w)
+	return err
+}
+
+// newTextDino creates a Dino for the text commands, where processes only come from inject
+func newTextDino(size int, seed int64) *Dino {
+	d := NewSeeded(size, seed)
+	d.DisableArrivals = true
+	return d
+}
+
+// runText runs the commands of RunInteractiveText on d, returning the Dino they left, which memory may have replaced
+func (d *Dino) runText(r io.Reader, w io.Writer) (*Dino, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		next, quit, err := d.runCommand(scanner.Text())
+		if quit {
+			return d, nil
+		}
+		d = next
+		d.refreshState()
+		d.writeText(w, err)
+	}
+	return d, scanner.Err()
+}
+
+// runCommand performs one of the commands of RunInteractiveText, returning the Dino to run the next one on (a new one
+// after memory) and whether it was quit
+func (d *Dino) runCommand(line string) (next *Dino, quit bool, err error) {
+	fields := strings.Fields(line)
+	switch command := fields[0]; {
+	case command == "quit" && len(fields) == 1:
+		return d, true, nil
+	case command == "memory" && (len(fields) == 2 || len(fields) == 3):
+		size, convErr := strconv.Atoi(fields[1])
+		if convErr != nil || size < 1 {
+			return d, false, fmt.Errorf("Cannot start over -- invalid size '%s'", fields[1])
+		}
+		seed := int64(0)
+		if len(fields) == 3 {
+			if seed, convErr = strconv.ParseInt(fields[2], 10, 64); convErr != nil {
+				return d, false, fmt.Errorf("Cannot start over -- invalid seed '%s'", fields[2])
+			}
+		}
+		return newTextDino(size, seed), false, nil
+	case command == "step" && len(fields) == 1:
+		err = d.stepKeepingHistory()
+	case command == "stepback" && len(fields) == 1:
+		err = d.RewindTo(d.step - 1)
+	case command == "compact" && len(fields) == 1:
+		err = d.Compact()
+	case command == "inject" && (len(fields) == 3 || len(fields) == 4):
+		size, convErr := strconv.Atoi(fields[2])
+		if convErr != nil || size < 1 {
+			return d, false, fmt.Errorf("Cannot inject -- invalid size '%s'", fields[2])
+		}
+		bursts := Bursts{}
+		if len(fields) == 4 {
+			n, convErr := strconv.Atoi(fields[3])
+			if convErr != nil || n < 1 {
+				return d, false, fmt.Errorf("Cannot inject -- invalid burst count '%s'", fields[3])
+			}
+			for ; n > 0; n-- {
+				bursts = append(bursts, BT_CPU)
+			}
+		} else {
+			bursts = randomBursts(d.rand, PT_INTERACTIVE, 3, 10)
+		}
+		err = d.Inject(&Process{
+			ID:            fields[1],
+			Name:          fields[1],
+			Type:          PT_INTERACTIVE,
+			Bursts:        bursts,
+			SizeInKB:      size,
+			MemoryAddress: -1,
+		})
+	default:
+		err = fmt.Errorf("Unknown command -- '%s'", line)
+	}
+	return d, false, err
+}
+
+// stepKeepingHistory runs a step, saving the Dino before and after it for stepback. A failed save doesn't hold the
+// step back, it's only reported
+func (d *Dino) stepKeepingHistory() error {
+	var saveErr error
+	if _, ok := d.history[d.step]; !ok {
+		saveErr = d.recordHistory()
+	}
+	if _, err := d.Step(); err != nil {
+		return err
+	} else if err := d.recordHistory(); saveErr == nil {
+		saveErr = err
+	}
+	if saveErr != nil {
+		return fmt.Errorf("Cannot keep history -- %s", saveErr.Error())
+	}
+	return nil
+}
+
+// writeText renders the state of the Dino in a few lines of plain text, headed by err if the last command failed
+func (d *Dino) writeText(w io.Writer, err error) {
+	names := func(ps Processes) string {
+		list := []string{}
+		for _, p := range ps {
+			list = append(list, p.Name)
+		}
+		return strings.Join(list, " ")
+	}
+	message := d.state.Message
+	if err != nil {
+		message = "error: " + err.Error()
+	}
+	fmt.Fprintf(w, "[step %d] %s\n", d.step, message)
+	fmt.Fprintf(w, "memory  %s\n", string(d.Memory.Map()))
+	fmt.Fprintf(w, "free    %d KB\n", d.Memory.TotalFree())
+	fmt.Fprintf(w, "new     %s\n", names(d.newQueue.Processes()))
+	fmt.Fprintf(w, "ready   %s\n", names(d.readyQueue.Processes()))
+}
@@ -0,0 +1,60 @@
+package dino
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunInteractiveText(t *testing.T) {
+	script := strings.NewReader("inject a 5\nstep\ninject b 10\nstep\n\nstepback\nbogus\ninject c big\ncompact\nquit\nstep\n")
+	var out bytes.Buffer
+	d, err := newTextDino(20, 1).runText(script, &out)
+	assert.NoError(t, err)
+
+	renders := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n[step ")
+	assert.Len(t, renders, 8, "One render per command until quit, none for the blank line")
+	assert.Contains(t, renders[0], "new     a\n")
+	assert.Contains(t, renders[1], "1] ")
+	assert.Contains(t, renders[1], "free    15 KB")
+	assert.Contains(t, renders[2], "new     b\n")
+	assert.Contains(t, renders[3], "2] ")
+	assert.Contains(t, renders[3], "free    5 KB")
+	assert.Contains(t, renders[4], "1] ", "Back to the first step")
+	assert.Contains(t, renders[4], "free    15 KB")
+	assert.Contains(t, renders[4], "new     \n", "b was injected after it")
+	assert.True(t, strings.HasPrefix(renders[5], "1] error: Unknown command -- 'bogus'\n"))
+	assert.True(t, strings.HasPrefix(renders[6], "1] error: Cannot inject -- invalid size 'big'\n"))
+	assert.Equal(t, 1, d.Metrics().Compactions)
+	assert.Equal(t, 1, d.StepCount(), "Nothing runs after quit")
+
+	// memory starts over with a new Dino
+	out.Reset()
+	assert.NoError(t, RunInteractiveText(strings.NewReader("inject a 5 4\nstep\nmemory 10\ninject a 5\nmemory big\n"), &out))
+	renders = strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n[step ")
+	assert.Len(t, renders, 5)
+	assert.Contains(t, renders[1], "free    195 KB", "The default memory")
+	assert.True(t, strings.HasPrefix(renders[2], "0] \n"))
+	assert.Contains(t, renders[2], "free    10 KB")
+	assert.Contains(t, renders[3], "new     a\n", "IDs of the old Dino are free again")
+	assert.True(t, strings.HasPrefix(renders[4], "0] error: Cannot start over -- invalid size 'big'\n"))
+}
+
+func TestRunInteractiveTextHistory(t *testing.T) {
+	d := newTextDino(20, 1)
+	d.SetScheduler(NewWFQScheduler("WFQ")) // Save can't rebuild it
+	script := strings.NewReader("inject a 5 4\ninject a 5\nstep\nstep\nstepback\n")
+	var out bytes.Buffer
+	d, err := d.runText(script, &out)
+	assert.NoError(t, err)
+
+	renders := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n[step ")
+	assert.Len(t, renders, 5)
+	assert.True(t, strings.HasPrefix(renders[1], "0] error: Cannot inject -- ID 'a' is already taken\n"))
+	assert.True(t, strings.HasPrefix(renders[2], "1] error: Cannot keep history -- "), "The step runs, but can't be stepped back")
+	assert.True(t, strings.HasPrefix(renders[3], "2] error: Cannot keep history -- "))
+	assert.True(t, strings.HasPrefix(renders[4], "2] error: Cannot rewind -- step 1 is not in the history\n"))
+	assert.Equal(t, 2, d.StepCount())
+}
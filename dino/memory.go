@@ -7,20 +7,61 @@ import (
 
 type Memory []*Process
 
+var (
+	// Allocation errors, to be checked with errors.Is
+	ErrNilProcess        = errors.New("Cannot allocate -- nil process")
+	ErrAlreadyAllocated  = errors.New("Cannot allocate -- process already in memory")
+	ErrOutOfBounds       = errors.New("Cannot allocate -- out of memory bound")
+	ErrSpaceOccupied     = errors.New("Cannot allocate -- space already occupied")
+	ErrMissingID         = errors.New("Cannot allocate -- please assign a (unique) ID to all your processes to unsafe memory operations")
+	ErrNoContiguousSpace = errors.New("There's not enough contiguous free space")
+	ErrInvalidSize       = errors.New("Cannot allocate -- size should be positive")
+)
+
+// allocationError is an error with its own message, that still matches one of the allocation errors with errors.Is
+type allocationError struct {
+	msg  string
+	kind error
+}
+
+func (e *allocationError) Error() string {
+	return e.msg
+}
+
+func (e *allocationError) Unwrap() error {
+	return e.kind
+}
+
 type MemoryLayout []*MemoryBlock
 type MemoryBlock struct {
-	Start int
-	Size  int
-	Name  string
+	Start   int
+	Size    int
+	Name    string
+	OwnerID string // ID of the process in the block, "" for free and reserved blocks
+	Pinned  bool   // the process in the block is pinned
 }
 
 const FREE_BLOCK = string('▓')
+const RESERVED_BLOCK = string('░')
+
+// reserved fills the cells no process can use. It's never allocated, released nor moved
+var reserved = &Process{ID: "\x00reserved", Name: RESERVED_BLOCK}
+
+// reservedTail is reserved for the cells of SetReservedTail, so it can tell them apart from the ones of Reserve
+var reservedTail = &Process{ID: "\x00reserved tail", Name: RESERVED_BLOCK}
+
+// isReservation reports whether p marks reserved cells rather than being a process
+func isReservation(p *Process) bool {
+	return p == reserved || p == reservedTail
+}
 
 func (m Memory) HasSpace(size int) bool {
 	_, _, err := m.WorstFit(size)
 	return err == nil
 }
 
+// WorstFit finds the largest hole in memory and reports whether sizeToFit fits in it.
+// When several holes share the maximum size, the lowest-addressed one is picked.
 func (m Memory) WorstFit(sizeToFit int) (start, offset int, err error) {
 	bestStart := -1
 	bestSize := 0
@@ -52,7 +93,25 @@ func (m Memory) WorstFit(sizeToFit int) (start, offset int, err error) {
 	}
 
 	if sizeToFit > bestSize {
-		err = errors.New("There's not enough contiguous free space")
+		err = ErrNoContiguousSpace
+	}
+
+	return bestStart, bestSize, err
+}
+
+// WorstFitLast is like WorstFit, but when several holes share the maximum size the highest-addressed one is picked
+func (m Memory) WorstFitLast(sizeToFit int) (start, offset int, err error) {
+	bestStart := -1
+	bestSize := 0
+	for _, hole := range m.holes() {
+		if hole.Size >= bestSize {
+			bestStart = hole.Start
+			bestSize = hole.Size
+		}
+	}
+
+	if sizeToFit > bestSize {
+		err = ErrNoContiguousSpace
 	}
 
 	return bestStart, bestSize, err
@@ -74,28 +133,41 @@ func (m Memory) isEmpty(start, offset int) bool {
 
 func (m Memory) checkBounds(start, offset int) error {
 	if start < 0 {
-		return errors.New("Cannot allocate -- start index should be non-negative")
+		return &allocationError{"Cannot allocate -- start index should be non-negative", ErrOutOfBounds}
 	} else if start+offset > len(m) {
-		return errors.New("Cannot allocate -- out of memory bound")
+		return ErrOutOfBounds
 	}
 	return nil
 }
 
 func (m Memory) Allocate(p *Process, start int) (err error) {
+	return m.AllocateStepwise(p, start, nil)
+}
+
+// AllocateStepwise performs the same allocation as Allocate, writing the cells one at a time in address order and
+// calling onCell after each of them
+func (m Memory) AllocateStepwise(p *Process, start int, onCell func(index int)) (err error) {
 	if p == nil {
-		return errors.New("Cannot allocate -- nil process")
+		return ErrNilProcess
 	} else if p.IsAllocated {
-		return errors.New("Cannot allocate -- process already in memory")
+		return ErrAlreadyAllocated
+	} else if p.SizeInKB < 1 {
+		return ErrInvalidSize
 	} else if err = m.checkBounds(start, p.SizeInKB); err != nil {
 		return err
 	} else if !m.isEmpty(start, p.SizeInKB) {
-		return errors.New("Cannot allocate -- space already occupied")
+		return ErrSpaceOccupied
 	} else if p.ID == "" {
-		return errors.New("Cannot allocate -- please assign a (unique) ID to all your processes to unsafe memory operations")
+		return ErrMissingID
+	} else if p.MaxAddress > 0 && start+p.SizeInKB-1 > p.MaxAddress {
+		return fmt.Errorf("Cannot allocate -- process '%s' can't reach past address %d", p.ID, p.MaxAddress)
 	}
 
 	for i := start; i < start+p.SizeInKB; i++ {
 		m[i] = p
+		if onCell != nil {
+			onCell(i)
+		}
 	}
 	p.IsAllocated = true
 	p.MemoryAddress = start
@@ -104,9 +176,9 @@ func (m Memory) Allocate(p *Process, start int) (err error) {
 
 func (m Memory) AllocateWorstFit(p *Process) (err error) {
 	if p == nil {
-		return errors.New("Cannot allocate -- nil process")
+		return ErrNilProcess
 	}
-	start, _, err := m.WorstFit(p.SizeInKB)
+	start, _, err := m.Reachable(p).WorstFit(p.SizeInKB)
 	if err != nil {
 		return err
 	}
@@ -127,6 +199,11 @@ func (m Memory) hardRelease(start, offset int) (err error) {
 }
 
 func (m Memory) ReleaseProcess(p *Process) (bool, error) {
+	if isPlaceholder(p) {
+		return false, errPlaceholder
+	} else if len(p.Fragments) > 0 {
+		return m.releaseFragments(p)
+	}
 	start := p.MemoryAddress
 	offset := p.SizeInKB
 
@@ -159,28 +236,434 @@ func (m Memory) ReleaseProcess(p *Process) (bool, error) {
 	return beenReleased, nil
 }
 
-func (m Memory) Layout() MemoryLayout {
-	layout := make(MemoryLayout, 0)
+// Reserve marks [start, start+size) as permanently unavailable: it's not free, but no process owns it
+func (m Memory) Reserve(start, size int) error {
+	if err := m.checkBounds(start, size); err != nil {
+		return err
+	} else if !m.isEmpty(start, size) {
+		return errors.New("Cannot reserve -- space already occupied")
+	}
 
-	var currentBlock *MemoryBlock
+	for i := start; i < start+size; i++ {
+		m[i] = reserved
+	}
+	return nil
+}
 
-	previousWasEmpty := false
+// Unreserve frees the reserved cells within [start, start+size), leaving any other cell untouched
+func (m Memory) Unreserve(start, size int) {
+	for i := start; i < start+size && i < len(m); i++ {
+		if i >= 0 && isReservation(m[i]) {
+			m[i] = nil
+		}
+	}
+}
 
-	for i, _ := range m {
-		if m[i] == nil && previousWasEmpty == false { // starting empty block
-			currentBlock = &MemoryBlock{Start: i, Size: 0, Name: FREE_BLOCK}
-			layout = append(layout, currentBlock)
-			previousWasEmpty = true
-		} else if m[i] != nil && i == 0 || m[i-1] != m[i] { // starting nonempty block
-			currentBlock = &MemoryBlock{Start: i, Size: 0, Name: m[i].Name}
-			layout = append(layout, currentBlock)
-			previousWasEmpty = false
+// SetReservedTail reserves the last k cells of memory, e.g. for the kernel, so no fit algorithm uses them and TotalFree
+// doesn't count them. Calling it again resizes the tail it reserved, leaving the cells reserved with Reserve as they are
+func (m Memory) SetReservedTail(k int) error {
+	if k < 0 || k > len(m) {
+		return fmt.Errorf("Cannot reserve tail -- invalid size %d", k)
+	}
+	tail := 0
+	for i := len(m) - 1; i >= 0 && isReservation(m[i]); i-- {
+		if m[i] == reservedTail {
+			tail = len(m) - i
+		}
+	}
+
+	m.reserveTail(tail, nil)
+	for i := len(m) - k; i < len(m); i++ {
+		if m[i] != nil && m[i] != reserved {
+			m.reserveTail(tail, reservedTail)
+			return errors.New("Cannot reserve tail -- space already occupied")
+		}
+	}
+	m.reserveTail(k, reservedTail)
+	return nil
+}
+
+// reserveTail sets the last k cells of memory that aren't reserved with Reserve to mark
+func (m Memory) reserveTail(k int, mark *Process) {
+	for i := len(m) - k; i < len(m); i++ {
+		if m[i] != reserved {
+			m[i] = mark
 		}
-		currentBlock.Size++
 	}
+}
+
+// TrimFreeTail reports how many cells at the end of memory are free, and could be given back without touching any
+// process. Reserved cells stop the count
+func (m Memory) TrimFreeTail() int {
+	tail := 0
+	for i := len(m) - 1; i >= 0 && m[i] == nil; i-- {
+		tail++
+	}
+	return tail
+}
+
+// WithoutFreeTail returns m shrunk by its TrimFreeTail cells. It shares the cells of m
+func (m Memory) WithoutFreeTail() Memory {
+	return m[:len(m)-m.TrimFreeTail()]
+}
+
+// Reachable returns the part of m that p can be placed in, up to its MaxAddress. Fit searches on it only find the
+// holes, or the parts of them, that p can reach. It shares the cells of m
+func (m Memory) Reachable(p *Process) Memory {
+	if p != nil && p.MaxAddress > 0 && p.MaxAddress+1 < len(m) {
+		return m[:p.MaxAddress+1]
+	}
+	return m
+}
+
+// isFixed reports whether the cell at index i can't be moved, being reserved, held by Reserve2P, pinned or a fragment
+// of a scattered process
+func (m Memory) isFixed(i int) bool {
+	return isReservation(m[i]) || isPlaceholder(m[i]) || (m[i] != nil && (m[i].Pinned || len(m[i].Fragments) > 0))
+}
+
+// IsReserved reports whether the cell at index i is reserved
+func (m Memory) IsReserved(i int) bool {
+	return isReservation(m[i])
+}
+
+// Compact slides every allocated process toward address 0, preserving their order, and returns the moved processes
+func (m Memory) Compact() ([]*Process, error) {
+	moved := []*Process{}
+	err := m.CompactStepwise(func(p *Process, from, to int) {
+		moved = append(moved, p)
+	})
+	return moved, err
+}
+
+// CompactStepwise performs the same relocations as Compact, one process at a time, calling onMove before each of them
+func (m Memory) CompactStepwise(onMove func(p *Process, from, to int)) error {
+	if err := m.checkClaims(); err != nil {
+		return err
+	}
+	for _, r := range m.CompactionPlan() {
+		if onMove != nil {
+			onMove(r.Process, r.From, r.To)
+		}
+		m.move(r.Process, r.To)
+	}
+	return nil
+}
+
+// CompactEnd slides every allocated process toward the last address, preserving their order, and returns the moved
+// processes. Free memory ends up at the lowest addresses, or right before reserved and pinned blocks, which stay in place
+// like the processes that can't reach their target
+func (m Memory) CompactEnd() ([]*Process, error) {
+	if err := m.checkClaims(); err != nil {
+		return nil, err
+	}
+	moved := []*Process{}
+	next := len(m) // processes are packed right before next
+	for end := len(m); end > 0; {
+		if m[end-1] == nil {
+			end--
+			continue
+		}
+		start := end - 1
+		for start > 0 && m[start-1] == m[end-1] {
+			start--
+		}
+		if p := m[start]; m.isFixed(start) || (p.MaxAddress > 0 && next-1 > p.MaxAddress) {
+			next = start
+		} else {
+			if end != next {
+				m.move(p, next-p.SizeInKB)
+				moved = append(moved, p)
+			}
+			next -= p.SizeInKB
+		}
+		end = start
+	}
+	return moved, nil
+}
+
+// CompactSmall is a cheaper partial compaction that only relocates processes of up to maxMoveSize KB, in address
+// order, each to the lowest-addressed hole it fits in. Larger processes stay in place like pinned ones, and free memory
+// coalesces around them. Returns the moved processes
+func (m Memory) CompactSmall(maxMoveSize int) ([]*Process, error) {
+	if err := m.checkClaims(); err != nil {
+		return nil, err
+	}
+	small := []*Process{}
+	for i := 0; i < len(m); i += m.blockSize(i) {
+		if p := m[i]; p != nil && !m.isFixed(i) && p.SizeInKB <= maxMoveSize {
+			small = append(small, p)
+		}
+	}
+
+	moved := []*Process{}
+	for _, p := range small {
+		from := p.MemoryAddress
+		for i := from; i < from+p.SizeInKB; i++ {
+			m[i] = nil
+		}
+		to := from
+		for _, hole := range m.holes() {
+			if hole.Start < to && hole.Size >= p.SizeInKB {
+				to = hole.Start
+				break
+			}
+		}
+		for i := from; i < from+p.SizeInKB; i++ {
+			m[i] = p
+		}
+		if to != from {
+			m.move(p, to)
+			moved = append(moved, p)
+		}
+	}
+	return moved, nil
+}
+
+// checkClaims verifies every process occupies exactly the cells it claims, so it can be moved. Scattered processes,
+// which never move, are skipped
+func (m Memory) checkClaims() error {
+	for i := 0; i < len(m); {
+		if m[i] == nil {
+			i++
+			continue
+		}
+		size := m.blockSize(i)
+		if !isReservation(m[i]) && len(m[i].Fragments) == 0 && (size != m[i].SizeInKB || m[i].MemoryAddress != i) {
+			return fmt.Errorf("Cannot compact -- process '%s' occupies [%d, %d) but claims [%d, %d)", m[i].ID, i, i+size, m[i].MemoryAddress, m[i].MemoryAddress+m[i].SizeInKB)
+		}
+		i += size
+	}
+	return nil
+}
+
+// ShiftLeft finds the first free gap at or after from, and moves the block that follows it to the start of the gap.
+// Reserved blocks can't move, so gaps right before them are skipped. Calling it until it fails compacts memory
+func (m Memory) ShiftLeft(from int) error {
+	if from < 0 {
+		return errors.New("Cannot shift -- start index should be non-negative")
+	}
+	for i := from; i < len(m); {
+		gap := i
+		for gap < len(m) && m[gap] != nil {
+			gap++
+		}
+		next := gap
+		for next < len(m) && m[next] == nil {
+			next++
+		}
+		if next == len(m) {
+			break
+		}
+
+		p := m[next]
+		if m.isFixed(next) {
+			i = next + m.blockSize(next)
+			continue
+		} else if size := m.blockSize(next); size != p.SizeInKB || p.MemoryAddress != next {
+			return fmt.Errorf("Cannot shift -- process '%s' occupies [%d, %d) but claims [%d, %d)", p.ID, next, next+size, p.MemoryAddress, p.MemoryAddress+p.SizeInKB)
+		}
+		m.move(p, gap)
+		return nil
+	}
+	return fmt.Errorf("Nothing to shift -- memory is already compact from %d on", from)
+}
+
+// EvictFor releases processes until there's a hole of at least size cells, picking each time the one whose release
+// makes the largest hole (the lowest-addressed among equals). Pinned and scattered processes are never evicted. If no
+// such hole can be made, nothing is evicted
+func (m Memory) EvictFor(size int) (evicted []*Process, err error) {
+	possible, run := 0, 0 // largest run of cells that are free or can be evicted
+	for i := range m {
+		if m.isFixed(i) {
+			run = 0
+		} else if run++; run > possible {
+			possible = run
+		}
+	}
+	if size > possible {
+		return nil, fmt.Errorf("Cannot evict -- no hole of %d KB can be made around pinned and reserved blocks", size)
+	}
+
+	evicted = []*Process{}
+	for m.LargestFreeBlock() < size {
+		var victim *Process
+		victimHole := 0
+		m.EachBlock(func(block MemoryBlock) bool {
+			if block.OwnerID != "" && !block.Pinned && len(m[block.Start].Fragments) == 0 {
+				left, right := m.NeighborsOf(m[block.Start])
+				if hole := left + block.Size + right; hole > victimHole {
+					victim, victimHole = m[block.Start], hole
+				}
+			}
+			return true
+		})
+		if _, err := m.ReleaseProcess(victim); err != nil {
+			return evicted, err
+		}
+		evicted = append(evicted, victim)
+	}
+	return evicted, nil
+}
+
+// Relocation is the move of a process from one address to another
+type Relocation struct {
+	Process  *Process
+	From, To int
+}
+
+// CompactionPlan lists, in order, the relocations Compact would perform, without performing them.
+// Reserved and pinned blocks stay in place, and processes after them are compacted toward their end
+func (m Memory) CompactionPlan() []Relocation {
+	plan := []Relocation{}
+	next := 0
+	for i := 0; i < len(m); {
+		if m[i] == nil {
+			i++
+			continue
+		}
+		size := m.blockSize(i)
+		if m.isFixed(i) {
+			next = i
+		} else if i != next {
+			plan = append(plan, Relocation{Process: m[i], From: i, To: next})
+		}
+		next += size
+		i += size
+	}
+	return plan
+}
+
+// ApplyPlan performs the given relocations in order. It stops at the first invalid one, leaving the previous applied
+func (m Memory) ApplyPlan(plan []Relocation) error {
+	for _, r := range plan {
+		p := r.Process
+		if p == nil {
+			return errors.New("Cannot relocate -- nil process")
+		} else if isReservation(p) {
+			return errors.New("Cannot relocate -- reserved memory can't be moved")
+		} else if p.Pinned {
+			return fmt.Errorf("Cannot relocate -- process '%s' is pinned", p.ID)
+		} else if len(p.Fragments) > 0 {
+			return fmt.Errorf("Cannot relocate -- process '%s' is scattered", p.ID)
+		} else if r.From < 0 || r.From >= len(m) || m[r.From] != p || p.MemoryAddress != r.From {
+			return fmt.Errorf("Cannot relocate -- process '%s' is not at address %d", p.ID, r.From)
+		} else if err := m.Reachable(p).checkBounds(r.To, p.SizeInKB); err != nil {
+			return err
+		}
+		for i := r.To; i < r.To+p.SizeInKB; i++ {
+			if m[i] != nil && m[i] != p {
+				return fmt.Errorf("Cannot relocate -- process '%s' would overwrite process '%s'", p.ID, m[i].ID)
+			}
+		}
+		m.move(p, r.To)
+	}
+	return nil
+}
+
+// blockSize returns the number of contiguous cells owned by the same process starting at start
+func (m Memory) blockSize(start int) int {
+	size := 0
+	for i := start; i < len(m) && m[i] == m[start]; i++ {
+		size++
+	}
+	return size
+}
+
+// NeighborsOf returns how many free cells there are right before and right after the block of p. Scattered processes
+// have no single block, so they have no neighbors
+func (m Memory) NeighborsOf(p *Process) (leftFree, rightFree int) {
+	if p == nil || !p.IsAllocated || len(p.Fragments) > 0 {
+		return 0, 0
+	}
+	for i := p.MemoryAddress - 1; i >= 0 && m[i] == nil; i-- {
+		leftFree++
+	}
+	for i := p.MemoryAddress + p.SizeInKB; i < len(m) && m[i] == nil; i++ {
+		rightFree++
+	}
+	return leftFree, rightFree
+}
+
+// Reallocate changes the size of an allocated process, in place when it shrinks or the cells after it are free, and
+// moving it to the worst fit hole (counting its own cells as free) otherwise, never past its MaxAddress. p is left
+// untouched if there's no space
+func (m Memory) Reallocate(p *Process, newSize int) error {
+	if p == nil {
+		return ErrNilProcess
+	} else if !p.IsAllocated {
+		return errors.New("Cannot reallocate -- process not in memory")
+	} else if len(p.Fragments) > 0 {
+		return fmt.Errorf("Cannot reallocate -- process '%s' is scattered", p.ID)
+	} else if newSize < 1 {
+		return fmt.Errorf("Cannot reallocate -- invalid size %d", newSize)
+	}
+
+	start, oldSize := p.MemoryAddress, p.SizeInKB
+	_, rightFree := m.NeighborsOf(p)
+	for i := start; i < start+oldSize; i++ {
+		m[i] = nil
+	}
+	if newSize > oldSize+rightFree || (p.MaxAddress > 0 && start+newSize-1 > p.MaxAddress) {
+		var err error
+		if start, _, err = m.Reachable(p).WorstFit(newSize); err != nil {
+			for i := p.MemoryAddress; i < p.MemoryAddress+oldSize; i++ {
+				m[i] = p
+			}
+			return err
+		}
+	}
+
+	for i := start; i < start+newSize; i++ {
+		m[i] = p
+	}
+	p.MemoryAddress = start
+	p.SizeInKB = newSize
+	return nil
+}
+
+// move relocates an allocated process to start. The destination must be free, except for cells of p itself
+func (m Memory) move(p *Process, start int) {
+	for i := p.MemoryAddress; i < p.MemoryAddress+p.SizeInKB; i++ {
+		m[i] = nil
+	}
+	for i := start; i < start+p.SizeInKB; i++ {
+		m[i] = p
+	}
+	p.MemoryAddress = start
+}
+
+func (m Memory) Layout() MemoryLayout {
+	layout := make(MemoryLayout, 0)
+	m.EachBlock(func(block MemoryBlock) bool {
+		layout = append(layout, &block)
+		return true
+	})
 	return layout
 }
 
+// EachBlock calls fn for every block of memory, free or occupied, in address order. Stops as soon as fn returns false
+func (m Memory) EachBlock(fn func(MemoryBlock) bool) {
+	for start := 0; start < len(m); {
+		end := start + 1
+		for end < len(m) && m[end] == m[start] {
+			end++
+		}
+		block := MemoryBlock{Start: start, Size: end - start, Name: FREE_BLOCK}
+		if m[start] != nil {
+			block.Name = m[start].Name
+		}
+		if m[start] != nil && !isReservation(m[start]) {
+			block.OwnerID = m[start].ID
+			block.Pinned = m[start].Pinned
+		}
+		if !fn(block) {
+			return
+		}
+		start = end
+	}
+}
+
 func (m Memory) TotalFree() int {
 	total := 0
 	for i := range m {
@@ -191,6 +674,113 @@ func (m Memory) TotalFree() int {
 	return total
 }
 
+// TotalOccupied counts the cells that aren't free, reserved ones included
+func (m Memory) TotalOccupied() int {
+	total := 0
+	for i := range m {
+		if m[i] != nil {
+			total++
+		}
+	}
+	return total
+}
+
+// UsageByProcess maps the ID of every process in memory to the number of cells it actually occupies
+func (m Memory) UsageByProcess() map[string]int {
+	usage := map[string]int{}
+	for i := range m {
+		if m[i] != nil && !isReservation(m[i]) {
+			usage[m[i].ID]++
+		}
+	}
+	return usage
+}
+
+// HoleCount returns the number of free blocks in memory
+func (m Memory) HoleCount() int {
+	holes := 0
+	for _, block := range m.Layout() {
+		if block.Name == FREE_BLOCK {
+			holes++
+		}
+	}
+	return holes
+}
+
+// MeanHoleSize returns the average size of the free blocks, or 0 if there are none
+func (m Memory) MeanHoleSize() float64 {
+	holes := m.HoleCount()
+	if holes == 0 {
+		return 0
+	}
+	return float64(m.TotalFree()) / float64(holes)
+}
+
+// LargestFreeBlock returns the size of the biggest hole in memory
+func (m Memory) LargestFreeBlock() int {
+	_, size, _ := m.WorstFit(0)
+	return size
+}
+
+// FragmentationRatio returns 1 - LargestFreeBlock/TotalFree: 0 when all free space is contiguous, approaching 1 as it
+// gets scattered in small holes. Memory without free space isn't fragmented.
+func (m Memory) FragmentationRatio() float64 {
+	free := m.TotalFree()
+	if free == 0 {
+		return 0
+	}
+	return 1 - float64(m.LargestFreeBlock())/float64(free)
+}
+
+// FFI returns the free fragmentation index used in the literature, 1 - largest free block / total free, 0 when memory is
+// full. It's the same as FragmentationRatio
+func (m Memory) FFI() float64 {
+	return m.FragmentationRatio()
+}
+
+// WeightedFFI is a variant of FFI that weighs every hole by its size instead of looking at the largest only:
+// 1 - sum(hole²) / total free². Several mid-sized holes score lower than a large one next to many slivers
+func (m Memory) WeightedFFI() float64 {
+	free := m.TotalFree()
+	if free == 0 {
+		return 0
+	}
+	squares := 0
+	for _, hole := range m.holes() {
+		squares += hole.Size * hole.Size
+	}
+	return 1 - float64(squares)/float64(free*free)
+}
+
+// FragReport summarizes how free memory is distributed
+type FragReport struct {
+	TotalFree          int
+	LargestFreeBlock   int
+	HoleCount          int
+	MeanHoleSize       float64
+	FragmentationRatio float64
+}
+
+// FragmentationReport computes every fragmentation metric in a single pass over the layout
+func (m Memory) FragmentationReport() FragReport {
+	report := FragReport{}
+	for _, block := range m.Layout() {
+		if block.Name != FREE_BLOCK {
+			continue
+		}
+		report.TotalFree += block.Size
+		report.HoleCount++
+		if block.Size > report.LargestFreeBlock {
+			report.LargestFreeBlock = block.Size
+		}
+	}
+	if report.HoleCount > 0 {
+		report.MeanHoleSize = float64(report.TotalFree) / float64(report.HoleCount)
+		report.FragmentationRatio = 1 - float64(report.LargestFreeBlock)/float64(report.TotalFree)
+	}
+	return report
+}
+
 func (ml MemoryLayout) String() string {
 	str := "\n\t\t------------ MemoryLayout ------------\n"
 	str += fmt.Sprintf("\t\t\t[init, size,  end]\t-\towner\n")
@@ -199,3 +789,65 @@ func (ml MemoryLayout) String() string {
 	}
 	return str
 }
+
+// Map returns one mark per cell: 'X' for occupied cells, 'P' for pinned ones, '-' for free ones and RESERVED_BLOCK for
+// reserved ones
+func (m Memory) Map() []rune {
+	marks := make([]rune, len(m))
+	for i := range m {
+		if m[i] == nil {
+			marks[i] = '-'
+		} else if isReservation(m[i]) {
+			marks[i] = []rune(RESERVED_BLOCK)[0]
+		} else if m[i].Pinned {
+			marks[i] = 'P'
+		} else {
+			marks[i] = 'X'
+		}
+	}
+	return marks
+}
+
+// Bitmap packs the occupancy of memory in bits, MSB first: 1 for occupied (or reserved) cells and 0 for free ones.
+// The last byte is padded with zeros, so the number of cells is returned too
+func (m Memory) Bitmap() (bitmap []byte, cells int) {
+	bitmap = make([]byte, (len(m)+7)/8)
+	for i := range m {
+		if m[i] != nil {
+			bitmap[i/8] |= 0x80 >> uint(i%8)
+		}
+	}
+	return bitmap, len(m)
+}
+
+// Equal reports whether both memories have the same length, and every cell is either free in both or holds processes
+// with the same ID
+func (m Memory) Equal(other Memory) bool {
+	if len(m) != len(other) {
+		return false
+	}
+	for i := range m {
+		if (m[i] == nil) != (other[i] == nil) || (m[i] != nil && m[i].ID != other[i].ID) {
+			return false
+		}
+	}
+	return true
+}
+
+// DumpAddresses lists every block as an inclusive hex address range followed by its owner, e.g. "0x0000-0x0013  p1"
+func (m Memory) DumpAddresses() string {
+	str := ""
+	m.EachBlock(func(block MemoryBlock) bool {
+		owner := block.Name
+		if block.Name == FREE_BLOCK {
+			owner = "free"
+		} else if block.Name == RESERVED_BLOCK {
+			owner = "reserved"
+		} else if block.Pinned {
+			owner += " (pinned)"
+		}
+		str += fmt.Sprintf("0x%04X-0x%04X  %s\n", block.Start, block.Start+block.Size-1, owner)
+		return true
+	})
+	return str
+}
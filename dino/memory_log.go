@@ -0,0 +1,68 @@
+package dino
+
+import (
+	"log"
+)
+
+// explicitStart is logged as the strategy of allocations at a start given by the caller
+const explicitStart = FitStrategy("Explicit")
+
+// scatterStrategy is logged as the strategy of AllocateScatter
+const scatterStrategy = FitStrategy("Scatter")
+
+// LoggedMemory wraps a Memory, logging every allocation and release made through it to Logger, with the start, size,
+// strategy and resulting free space. A nil Logger is silent
+type LoggedMemory struct {
+	Memory
+	Logger *log.Logger
+}
+
+func NewLoggedMemory(m Memory, logger *log.Logger) *LoggedMemory {
+	return &LoggedMemory{Memory: m, Logger: logger}
+}
+
+func (lm *LoggedMemory) Allocate(p *Process, start int) error {
+	if err := lm.Memory.Allocate(p, start); err != nil {
+		return err
+	}
+	lm.logAllocate(p, explicitStart)
+	return nil
+}
+
+func (lm *LoggedMemory) AllocateWorstFit(p *Process) error {
+	return lm.AllocateFit(p, FS_WORST_FIT)
+}
+
+func (lm *LoggedMemory) AllocateFit(p *Process, strategy FitStrategy) error {
+	if err := lm.Memory.AllocateFit(p, strategy); err != nil {
+		return err
+	}
+	lm.logAllocate(p, strategy)
+	return nil
+}
+
+func (lm *LoggedMemory) AllocateScatter(p *Process) ([]MemoryBlock, error) {
+	blocks, err := lm.Memory.AllocateScatter(p)
+	if err == nil {
+		lm.logAllocate(p, scatterStrategy)
+	}
+	return blocks, err
+}
+
+func (lm *LoggedMemory) ReleaseProcess(p *Process) (bool, error) {
+	if p == nil {
+		return false, ErrNilProcess
+	}
+	start := p.MemoryAddress
+	released, err := lm.Memory.ReleaseProcess(p)
+	if released && err == nil && lm.Logger != nil {
+		lm.Logger.Printf("release %s: start %d, size %dKB, %dKB free", p.ID, start, p.SizeInKB, lm.TotalFree())
+	}
+	return released, err
+}
+
+func (lm *LoggedMemory) logAllocate(p *Process, strategy FitStrategy) {
+	if lm.Logger != nil {
+		lm.Logger.Printf("allocate %s: start %d, size %dKB, strategy %s, %dKB free", p.ID, p.MemoryAddress, p.SizeInKB, strategy, lm.TotalFree())
+	}
+}
@@ -0,0 +1,40 @@
+package dino
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggedMemory(t *testing.T) {
+	var out bytes.Buffer
+	lm := NewLoggedMemory(make(Memory, 100), log.New(&out, "", 0))
+	p := &Process{ID: "p", Name: "p", SizeInKB: 30, MemoryAddress: -1}
+	assert.NoError(t, lm.Memory.AllocateFit(p, FS_FIRST_FIT))
+	lm.Memory.ReleaseProcess(p)
+	assert.Empty(t, out.String(), "Operations on the bare memory aren't logged")
+
+	assert.NoError(t, lm.AllocateFit(p, FS_BEST_FIT))
+	q := &Process{ID: "q", Name: "q", SizeInKB: 10, MemoryAddress: -1}
+	assert.NoError(t, lm.Allocate(q, 50))
+	lm.ReleaseProcess(p)
+	r := &Process{ID: "r", Name: "r", SizeInKB: 70, MemoryAddress: -1}
+	_, err := lm.AllocateScatter(r)
+	assert.NoError(t, err)
+	assert.Error(t, lm.Allocate(q, 0), "Failed allocations aren't logged")
+	assert.Equal(t, "allocate p: start 0, size 30KB, strategy Best Fit, 70KB free\n"+
+		"allocate q: start 50, size 10KB, strategy Explicit, 60KB free\n"+
+		"release p: start 0, size 30KB, 90KB free\n"+
+		"allocate r: start 0, size 70KB, strategy Scatter, 20KB free\n", out.String())
+
+	out.Reset()
+	lm.Logger = nil
+	lm.ReleaseProcess(r)
+	assert.NoError(t, lm.AllocateFit(p, FS_FIRST_FIT))
+	assert.Empty(t, out.String(), "A nil logger is silent")
+
+	_, err = lm.ReleaseProcess(nil)
+	assert.Equal(t, ErrNilProcess, err)
+}
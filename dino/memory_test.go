@@ -1,6 +1,8 @@
 package dino
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -227,3 +229,1178 @@ func TestTotalFree(t *testing.T) {
 	m := createTestMemory()
 	assert.Equal(t, 28, m.TotalFree())
 }
+
+func createAllocatedTestMemory() Memory {
+	m := make(Memory, 100)
+
+	// Same free segments as createTestMemory, but with consistent processes
+	m.Allocate(&Process{ID: "process1_", Name: "0001", SizeInKB: 10}, 0)
+	m.Allocate(&Process{ID: "process2_", Name: "0002", SizeInKB: 10}, 15)
+	m.Allocate(&Process{ID: "process3_", Name: "0003", SizeInKB: 11}, 30)
+	m.Allocate(&Process{ID: "process4_", Name: "0004", SizeInKB: 9}, 43)
+	m.Allocate(&Process{ID: "process5_", Name: "0005", SizeInKB: 22}, 61)
+	m.Allocate(&Process{ID: "process6_", Name: "0006", SizeInKB: 10}, 90)
+
+	return m
+}
+
+func TestCompact(t *testing.T) {
+	m := createAllocatedTestMemory()
+	moved, err := m.Compact()
+	assert.NoError(t, err)
+	assert.Len(t, moved, 5)
+
+	layout := m.Layout()
+	assert.Len(t, layout, 7)
+	assert.Equal(t, 72, layout[6].Start)
+	assert.Equal(t, 28, layout[6].Size)
+	assert.Equal(t, FREE_BLOCK, layout[6].Name)
+	for i := 0; i < 72; i++ {
+		assert.True(t, m[i].MemoryAddress <= i && i < m[i].MemoryAddress+m[i].SizeInKB)
+	}
+
+	m[0].MemoryAddress = 3
+	_, err = m.Compact()
+	assert.Error(t, err)
+}
+
+func TestAllocateStepwise(t *testing.T) {
+	m := createTestMemory()
+	p := &Process{ID: "p", Name: "p", SizeInKB: 6}
+	touched := []int{}
+	err := m.AllocateStepwise(p, 52, func(i int) {
+		assert.True(t, m[i] == p, "The cell is written before the callback")
+		assert.Nil(t, m[i+1], "The next cell isn't written yet")
+		touched = append(touched, i)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{52, 53, 54, 55, 56, 57}, touched)
+
+	expected := createTestMemory()
+	assert.NoError(t, expected.Allocate(&Process{ID: "p", Name: "p", SizeInKB: 6}, 52))
+	assert.True(t, expected.Equal(m))
+	assert.Equal(t, expected.Layout(), m.Layout())
+	assert.True(t, p.IsAllocated)
+	assert.Equal(t, 52, p.MemoryAddress)
+
+	touched = []int{}
+	err = m.AllocateStepwise(&Process{ID: "q", SizeInKB: 6}, 50, func(i int) { touched = append(touched, i) })
+	assert.True(t, errors.Is(err, ErrSpaceOccupied))
+	assert.Empty(t, touched, "Failed allocations write nothing")
+}
+
+func TestReserve2P(t *testing.T) {
+	// Free segments of 5, 5, 2, 9 and 7 cells at 10, 25, 41, 52 and 83
+	m := createTestMemory()
+	p := &Process{ID: "p", Name: "p", SizeInKB: 8}
+	reservation, err := m.Reserve2P(p, FS_WORST_FIT)
+	assert.NoError(t, err)
+	assert.Equal(t, 52, reservation.Start)
+	assert.False(t, p.IsAllocated, "Not allocated until committed")
+	assert.Equal(t, 20, m.TotalFree(), "The hole is held")
+
+	// A second reservation can't take the held hole
+	q := &Process{ID: "q", Name: "q", SizeInKB: 6}
+	other, err := m.Reserve2P(q, FS_WORST_FIT)
+	assert.NoError(t, err)
+	assert.Equal(t, 83, other.Start)
+	_, err = m.Reserve2P(&Process{ID: "r", SizeInKB: 8}, FS_FIRST_FIT)
+	assert.True(t, errors.Is(err, ErrNoContiguousSpace))
+
+	assert.NoError(t, reservation.Commit())
+	assert.True(t, p.IsAllocated)
+	assert.Equal(t, 52, p.MemoryAddress)
+	assert.True(t, m[52] == p && m[59] == p)
+	assert.Error(t, reservation.Commit(), "Already committed")
+	_, err = m.ReleaseProcess(p)
+	assert.NoError(t, err)
+
+	other.Abort()
+	assert.Nil(t, m[83])
+	assert.False(t, q.IsAllocated)
+	assert.Error(t, other.Commit(), "Aborted")
+	assert.Equal(t, createTestMemory().Layout(), m.Layout())
+	other.Abort()
+	assert.Equal(t, createTestMemory().Layout(), m.Layout(), "Aborting twice does nothing")
+}
+
+func TestReserve2PHeldHole(t *testing.T) {
+	m := make(Memory, 30)
+	a := &Process{ID: "a", Name: "a", SizeInKB: 10, MemoryAddress: -1}
+	assert.NoError(t, m.Allocate(a, 0))
+	p := &Process{ID: "p", Name: "p", SizeInKB: 10, MemoryAddress: -1}
+	reservation, err := m.Reserve2P(p, FS_BEST_FIT)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, reservation.Start)
+
+	// Compaction doesn't move the placeholder
+	_, err = m.ReleaseProcess(a)
+	assert.NoError(t, err)
+	_, err = m.Compact()
+	assert.NoError(t, err)
+	assert.Nil(t, m[0])
+	assert.True(t, m.isFixed(10))
+
+	// Releasing doesn't free the held cells, neither through the placeholder nor through the process
+	_, err = m.ReleaseProcess(m[10])
+	assert.Error(t, err)
+	p.MemoryAddress = 10
+	_, err = m.ReleaseProcess(p)
+	assert.Error(t, err)
+	p.MemoryAddress = -1
+	assert.Equal(t, 20, m.TotalFree())
+
+	// A process allocated elsewhere in the meantime can't be committed, and its hole stays held
+	assert.NoError(t, m.AllocateFit(p, FS_FIRST_FIT))
+	assert.True(t, errors.Is(reservation.Commit(), ErrAlreadyAllocated))
+	assert.Equal(t, 0, p.MemoryAddress)
+	assert.Equal(t, 10, m.TotalFree())
+	reservation.Abort()
+	assert.Equal(t, 20, m.TotalFree())
+}
+
+func TestCompactSmall(t *testing.T) {
+	m := createAllocatedTestMemory()
+	p3, p5 := m[30], m[61]
+	moved, err := m.CompactSmall(10)
+	assert.NoError(t, err)
+	assert.Len(t, moved, 3, "The first process is already at the start")
+	assert.Equal(t, 30, p3.MemoryAddress, "Large processes don't move")
+	assert.Equal(t, 61, p5.MemoryAddress)
+
+	holes := []int{}
+	for _, hole := range m.holes() {
+		holes = append(holes, hole.Size)
+	}
+	assert.Equal(t, []int{1, 10, 17}, holes, "Small processes fill the holes before the large ones")
+	assert.Equal(t, "process4_", m[20].ID, "It jumped to the first hole it fits in")
+	assert.Equal(t, "process6_", m[41].ID)
+
+	moved, err = m.CompactSmall(10)
+	assert.NoError(t, err)
+	assert.Empty(t, moved)
+}
+
+func TestCompactEnd(t *testing.T) {
+	m := createAllocatedTestMemory()
+	first, last := m[0], m[99]
+	moved, err := m.CompactEnd()
+	assert.NoError(t, err)
+	assert.Len(t, moved, 5, "The last process is already at the end")
+
+	layout := m.Layout()
+	assert.Len(t, layout, 7)
+	assert.Equal(t, 0, layout[0].Start)
+	assert.Equal(t, 28, layout[0].Size)
+	assert.Equal(t, FREE_BLOCK, layout[0].Name)
+	assert.Equal(t, first.ID, layout[1].OwnerID, "Order is preserved")
+	assert.Equal(t, 28, first.MemoryAddress)
+	assert.Equal(t, 90, last.MemoryAddress)
+	for i := 28; i < 100; i++ {
+		assert.True(t, m[i].MemoryAddress <= i && i < m[i].MemoryAddress+m[i].SizeInKB)
+	}
+
+	// Reserved blocks stay in place, processes before them are packed against them
+	m = make(Memory, 20)
+	p := &Process{ID: "p", SizeInKB: 3}
+	m.Allocate(p, 2)
+	assert.NoError(t, m.Reserve(10, 2))
+	_, err = m.CompactEnd()
+	assert.NoError(t, err)
+	assert.Equal(t, 7, p.MemoryAddress)
+
+	m[7].MemoryAddress = 3
+	_, err = m.CompactEnd()
+	assert.Error(t, err)
+}
+
+func TestCompactAroundPinned(t *testing.T) {
+	// [0,3) free, a at [3,6), [6,8) free, kernel pinned at [8,10), [10,14) free, b at [14,16)
+	m := make(Memory, 20)
+	a := &Process{ID: "a", Name: "a", SizeInKB: 3}
+	kernel := &Process{ID: "kernel", Name: "kernel", SizeInKB: 2, Pinned: true}
+	b := &Process{ID: "b", Name: "b", SizeInKB: 2}
+	m.Allocate(a, 3)
+	m.Allocate(kernel, 8)
+	m.Allocate(b, 14)
+
+	assert.Equal(t, []rune("---XXX--PP----XX----"), m.Map())
+	assert.Contains(t, m.DumpAddresses(), "0x0008-0x0009  kernel (pinned)\n")
+	assert.True(t, m.Layout()[3].Pinned)
+
+	moved, err := m.Compact()
+	assert.NoError(t, err)
+	assert.Equal(t, []*Process{a, b}, moved)
+	assert.Equal(t, 0, a.MemoryAddress)
+	assert.Equal(t, 8, kernel.MemoryAddress, "Pinned processes stay in place")
+	assert.Equal(t, 10, b.MemoryAddress, "Processes after a pinned one are compacted toward its end")
+
+	_, err = m.CompactEnd()
+	assert.NoError(t, err)
+	assert.Equal(t, 5, a.MemoryAddress)
+	assert.Equal(t, 8, kernel.MemoryAddress)
+	assert.Equal(t, 18, b.MemoryAddress)
+
+	assert.Error(t, m.ApplyPlan([]Relocation{{Process: kernel, From: 8, To: 0}}))
+	assert.False(t, m.CanFitAll([]*Process{{ID: "big", SizeInKB: 11}}), "The pinned block splits free memory")
+}
+
+func TestEvictFor(t *testing.T) {
+	// kernel pinned at [0,4), a at [4,6), b at [6,9), c at [9,11), free [11,14)
+	m := make(Memory, 14)
+	kernel := &Process{ID: "kernel", Name: "kernel", SizeInKB: 4, Pinned: true}
+	a := &Process{ID: "a", Name: "a", SizeInKB: 2}
+	b := &Process{ID: "b", Name: "b", SizeInKB: 3}
+	c := &Process{ID: "c", Name: "c", SizeInKB: 2}
+	m.Allocate(kernel, 0)
+	m.Allocate(a, 4)
+	m.Allocate(b, 6)
+	m.Allocate(c, 9)
+
+	evicted, err := m.EvictFor(4)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Process{c}, evicted, "Evicting c makes a 5 KB hole with the free cells after it")
+
+	evicted, err = m.EvictFor(10)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Process{b, a}, evicted)
+	assert.True(t, kernel.IsAllocated, "Pinned processes are never evicted")
+
+	_, err = m.EvictFor(11)
+	assert.Error(t, err)
+	assert.True(t, m[0] == kernel)
+}
+
+func TestCompactStepwise(t *testing.T) {
+	type move struct {
+		p        *Process
+		from, to int
+	}
+	m := createAllocatedTestMemory()
+	moves := []move{}
+	err := m.CompactStepwise(func(p *Process, from, to int) {
+		assert.Equal(t, from, p.MemoryAddress, "The callback should run before the relocation")
+		moves = append(moves, move{p, from, to})
+	})
+	assert.NoError(t, err)
+
+	expected := []struct {
+		id       string
+		from, to int
+	}{
+		{"process2_", 15, 10},
+		{"process3_", 30, 20},
+		{"process4_", 43, 31},
+		{"process5_", 61, 40},
+		{"process6_", 90, 62},
+	}
+	assert.Len(t, moves, len(expected))
+	for i := range expected {
+		assert.Equal(t, expected[i].id, moves[i].p.ID)
+		assert.Equal(t, expected[i].from, moves[i].from)
+		assert.Equal(t, expected[i].to, moves[i].to)
+	}
+
+	compacted := createAllocatedTestMemory()
+	compacted.Compact()
+	for i := range m {
+		if compacted[i] == nil {
+			assert.Nil(t, m[i])
+		} else {
+			assert.Equal(t, compacted[i].ID, m[i].ID)
+			assert.Equal(t, compacted[i].MemoryAddress, m[i].MemoryAddress)
+		}
+	}
+}
+
+func TestHoleCount(t *testing.T) {
+	m := createTestMemory()
+	assert.Equal(t, 5, m.HoleCount())
+	assert.InDelta(t, 28.0/5.0, m.MeanHoleSize(), 0.0001)
+
+	empty := make(Memory, 100)
+	assert.Equal(t, 1, empty.HoleCount())
+	assert.InDelta(t, 100.0, empty.MeanHoleSize(), 0.0001)
+
+	full := make(Memory, 10)
+	full.Allocate(&Process{ID: "process10_", SizeInKB: 10}, 0)
+	assert.Equal(t, 0, full.HoleCount())
+	assert.Equal(t, 0.0, full.MeanHoleSize())
+
+	edges := make(Memory, 10)
+	edges.Allocate(&Process{ID: "process6_", SizeInKB: 6}, 3)
+	assert.Equal(t, 2, edges.HoleCount())
+	assert.InDelta(t, 2.0, edges.MeanHoleSize(), 0.0001)
+}
+
+func TestFit(t *testing.T) {
+	m := createTestMemory()
+
+	start, size, err := m.Fit(5, FS_FIRST_FIT)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, start)
+	assert.Equal(t, 5, size)
+
+	start, size, err = m.Fit(6, FS_BEST_FIT)
+	assert.NoError(t, err)
+	assert.Equal(t, 83, start)
+	assert.Equal(t, 7, size)
+
+	start, _, err = m.Fit(6, FS_WORST_FIT)
+	assert.NoError(t, err)
+	assert.Equal(t, 52, start)
+
+	_, _, err = m.Fit(10, FS_FIRST_FIT)
+	assert.EqualError(t, err, "There's not enough contiguous free space")
+	_, _, err = m.Fit(10, FS_BEST_FIT)
+	assert.EqualError(t, err, "There's not enough contiguous free space")
+	_, _, err = m.Fit(1, FitStrategy("Random Fit"))
+	assert.Error(t, err)
+}
+
+func TestAllocateAndLayout(t *testing.T) {
+	m := createTestMemory()
+	p := &Process{ID: "new", Name: "new", SizeInKB: 6}
+	layout, err := m.AllocateAndLayout(p, FS_BEST_FIT)
+	assert.NoError(t, err)
+	assert.Equal(t, m.Layout(), layout)
+	found := false
+	for _, block := range layout {
+		if block.OwnerID == "new" {
+			found = true
+			assert.Equal(t, 83, block.Start)
+			assert.Equal(t, 6, block.Size)
+		}
+	}
+	assert.True(t, found, "The layout should show the new process")
+
+	before := m.Layout()
+	layout, err = m.AllocateAndLayout(&Process{ID: "huge", SizeInKB: 10}, FS_BEST_FIT)
+	assert.Error(t, err)
+	assert.Nil(t, layout)
+	assert.Equal(t, before, m.Layout())
+}
+
+func TestMaxAddress(t *testing.T) {
+	fresh := func() Memory {
+		m := make(Memory, 100)
+		m.Allocate(&Process{ID: "low", SizeInKB: 10}, 0)
+		m.Allocate(&Process{ID: "mid", SizeInKB: 15}, 20)
+		return m // free: 10 cells at 10 and 65 at 35
+	}
+	for _, strategy := range []FitStrategy{FS_FIRST_FIT, FS_BEST_FIT, FS_WORST_FIT, FS_WORST_FIT_LAST, FS_BALANCED_FIT} {
+		m := fresh()
+		p := &Process{ID: "p", SizeInKB: 10, MaxAddress: 49}
+		assert.NoError(t, m.AllocateFit(p, strategy), string(strategy))
+		assert.True(t, p.MemoryAddress+p.SizeInKB-1 <= p.MaxAddress, "%s placed it at %d", strategy, p.MemoryAddress)
+	}
+
+	m := fresh()
+	p := &Process{ID: "p", SizeInKB: 10, MaxAddress: 49}
+	assert.NoError(t, m.AllocateWorstFit(p))
+	assert.Equal(t, 35, p.MemoryAddress, "The largest hole it can reach is the one straddling its limit")
+
+	q := &Process{ID: "q", SizeInKB: 12, MaxAddress: 49}
+	assert.Error(t, m.Allocate(q, 50))
+	assert.Equal(t, ErrNoContiguousSpace, m.AllocateWorstFit(q), "Only higher holes are left")
+	assert.False(t, q.IsAllocated)
+	q.MaxAddress = 0
+	assert.NoError(t, m.AllocateWorstFit(q))
+	assert.Equal(t, 45, q.MemoryAddress)
+
+	// The Dino doesn't admit it while only unreachable holes fit it
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	d.Memory.Allocate(&Process{ID: "low", SizeInKB: 50}, 0)
+	r := &Process{ID: "r", Name: "r", Type: PT_INTERACTIVE, SizeInKB: 10, MaxAddress: 50, Bursts: Bursts{BT_CPU}, MemoryAddress: -1}
+	d.Inject(r)
+	_, err := d.Step()
+	assert.NoError(t, err)
+	assert.False(t, r.IsAllocated)
+}
+
+func TestMaxAddressEveryPath(t *testing.T) {
+	fresh := func() Memory {
+		m := make(Memory, 100)
+		m.Allocate(&Process{ID: "low", SizeInKB: 10}, 0)
+		m.Allocate(&Process{ID: "mid", SizeInKB: 15}, 20)
+		return m // free: 10 cells at 10 and 65 at 35
+	}
+	// limited only reaches the hole at 10, although the one at 35 is larger and more recent
+	limited := func() *Process {
+		return &Process{ID: "p", Name: "p", SizeInKB: 10, MaxAddress: 30, MemoryAddress: -1}
+	}
+
+	m := fresh()
+	p := limited()
+	assert.NoError(t, m.AllocateMinLeftover(p, 0))
+	assert.Equal(t, 10, p.MemoryAddress, "AllocateMinLeftover")
+
+	m = fresh()
+	p = limited()
+	reservation, err := m.Reserve2P(p, FS_WORST_FIT)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, reservation.Start, "Reserve2P")
+
+	hm := NewHookedMemory(fresh())
+	p = limited()
+	assert.NoError(t, hm.AllocateWorstFit(p))
+	assert.Equal(t, 10, p.MemoryAddress, "HookedMemory")
+
+	list := NewFreeListMemory(fresh())
+	p = limited()
+	assert.NoError(t, list.AllocateWorstFit(p))
+	assert.Equal(t, 10, p.MemoryAddress, "FreeListMemory")
+
+	recent := NewRecentFirstAllocator(fresh())
+	hot := &Process{ID: "hot", SizeInKB: 10, MemoryAddress: -1}
+	assert.NoError(t, recent.Memory.Allocate(hot, 60))
+	_, err = recent.ReleaseProcess(hot)
+	assert.NoError(t, err)
+	p = limited()
+	assert.NoError(t, recent.Allocate(p))
+	assert.Equal(t, 10, p.MemoryAddress, "RecentFirstAllocator")
+
+	zoned, err := NewZonedMemory(fresh(), 2)
+	assert.NoError(t, err)
+	p = limited()
+	assert.True(t, errors.Is(zoned.AllocateInZone(p, 1, false), ErrZoneFull))
+	assert.NoError(t, zoned.AllocateInZone(p, 1, true))
+	assert.Equal(t, 10, p.MemoryAddress, "AllocateInZone")
+
+	// After the cursor only [5, 20) and unreachable holes are left, so it wraps around
+	m = make(Memory, 100)
+	m.Allocate(&Process{ID: "a", SizeInKB: 10}, 20)
+	ring := NewRingAllocator(m)
+	filler := &Process{ID: "filler", SizeInKB: 5, MemoryAddress: -1}
+	assert.NoError(t, ring.Allocate(filler))
+	m.ReleaseProcess(filler)
+	p = &Process{ID: "p", SizeInKB: 16, MaxAddress: 25, MemoryAddress: -1}
+	assert.NoError(t, ring.Allocate(p))
+	assert.Equal(t, 0, p.MemoryAddress, "RingAllocator")
+
+	// Free: 5 cells at 0 and 10, the rest from 20 on
+	m = make(Memory, 100)
+	m.Allocate(&Process{ID: "a", SizeInKB: 5}, 5)
+	m.Allocate(&Process{ID: "b", SizeInKB: 5}, 15)
+	p = &Process{ID: "p", Name: "p", SizeInKB: 8, MaxAddress: 14, MemoryAddress: -1}
+	fragments, err := m.AllocateScatter(p)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemoryBlock{{Start: 0, Size: 5, Name: "p", OwnerID: "p"}, {Start: 10, Size: 3, Name: "p", OwnerID: "p"}}, fragments, "AllocateScatter")
+
+	// Growing past the limit moves it, to the largest hole it can reach
+	m = fresh()
+	p = &Process{ID: "p", SizeInKB: 10, MaxAddress: 49, MemoryAddress: -1}
+	assert.NoError(t, m.Allocate(p, 40))
+	assert.NoError(t, m.Reallocate(p, 12))
+	assert.Equal(t, 35, p.MemoryAddress, "Reallocate")
+	assert.True(t, errors.Is(m.Reallocate(p, 16), ErrNoContiguousSpace))
+	assert.Equal(t, 12, p.SizeInKB)
+
+	assert.Error(t, m.ApplyPlan([]Relocation{{Process: p, From: 35, To: 80}}))
+	assert.Equal(t, 35, p.MemoryAddress, "ApplyPlan")
+
+	// p can't reach the end, so it stays in place like a pinned process
+	m = fresh()
+	p = &Process{ID: "p", SizeInKB: 10, MaxAddress: 20, MemoryAddress: -1}
+	assert.NoError(t, m.Allocate(p, 10))
+	_, err = m.CompactEnd()
+	assert.NoError(t, err)
+	assert.Equal(t, 10, p.MemoryAddress, "CompactEnd")
+	assert.Equal(t, 85, m[99].MemoryAddress)
+	assert.Equal(t, 0, m[0].MemoryAddress)
+}
+
+func TestAllocateLowFrag(t *testing.T) {
+	// Free segments of 5, 5, 2, 9 and 7 cells at 10, 25, 41, 52 and 83
+	lowFrag, worst := createTestMemory(), createTestMemory()
+	for i, size := range []int{5, 5, 2, 4} {
+		id := fmt.Sprintf("p%d", i)
+		assert.NoError(t, lowFrag.AllocateLowFrag(&Process{ID: id, SizeInKB: size}))
+		assert.NoError(t, worst.AllocateWorstFit(&Process{ID: id, SizeInKB: size}))
+	}
+	assert.Equal(t, 2, lowFrag.HoleCount(), "Exact fits closed three holes")
+	assert.Equal(t, 5, worst.HoleCount(), "Worst fit only shrank them")
+	assert.Equal(t, "p3", lowFrag[52].ID, "The 9 cell hole leaves room for another 4, the 7 cell one doesn't")
+	assert.Equal(t, ErrNilProcess, lowFrag.AllocateLowFrag(nil))
+}
+
+func TestAllocateSelect(t *testing.T) {
+	// Free segments of 5, 5, 2, 9 and 7 cells at 10, 25, 41, 52 and 83
+	m := createTestMemory()
+	start, size, err := m.Select(5, SelectWorst)
+	assert.NoError(t, err)
+	assert.Equal(t, 52, start)
+	assert.Equal(t, 9, size)
+
+	last := func(holes []MemoryBlock) int { return len(holes) - 1 }
+	p := &Process{ID: "last", SizeInKB: 5}
+	assert.NoError(t, m.AllocateSelect(p, last))
+	assert.Equal(t, 83, p.MemoryAddress)
+
+	// Prefers the hole closest to twice the size, leaving room for a sibling
+	double := func(size int) HoleSelector {
+		distance := func(hole MemoryBlock) int {
+			if hole.Size > 2*size {
+				return hole.Size - 2*size
+			}
+			return 2*size - hole.Size
+		}
+		return func(holes []MemoryBlock) int {
+			closest := 0
+			for i, hole := range holes {
+				if distance(hole) < distance(holes[closest]) {
+					closest = i
+				}
+			}
+			return closest
+		}
+	}
+	p = &Process{ID: "double", SizeInKB: 4}
+	assert.NoError(t, m.AllocateSelect(p, double(4)))
+	assert.Equal(t, 52, p.MemoryAddress)
+
+	assert.True(t, errors.Is(m.AllocateSelect(&Process{ID: "huge", SizeInKB: 10}, SelectFirst), ErrNoContiguousSpace))
+	assert.Error(t, m.AllocateSelect(&Process{ID: "lost", SizeInKB: 1}, func([]MemoryBlock) int { return 99 }))
+	assert.True(t, errors.Is(m.AllocateSelect(nil, SelectBest), ErrNilProcess))
+}
+
+func TestFragmentationRatio(t *testing.T) {
+	m := createTestMemory()
+	assert.Equal(t, 9, m.LargestFreeBlock())
+	assert.InDelta(t, 1-9.0/28.0, m.FragmentationRatio(), 0.0001)
+
+	assert.Equal(t, 0.0, make(Memory, 10).FragmentationRatio())
+}
+
+func TestFFI(t *testing.T) {
+	// Free segments of 5, 5, 2, 9 and 7 cells
+	m := createTestMemory()
+	assert.InDelta(t, 1-9.0/28.0, m.FFI(), 0.0001)
+	assert.InDelta(t, 1-184.0/784.0, m.WeightedFFI(), 0.0001)
+
+	// Two equal holes: [0,5) and [15,20)
+	m = make(Memory, 20)
+	m.Allocate(&Process{ID: "wall", SizeInKB: 10}, 5)
+	assert.Equal(t, 0.5, m.FFI())
+	assert.Equal(t, 0.5, m.WeightedFFI())
+
+	// A large hole and a sliver: [0,1) and [2,20)
+	m = make(Memory, 20)
+	m.Allocate(&Process{ID: "wall", SizeInKB: 1}, 1)
+	assert.InDelta(t, 1-18.0/19.0, m.FFI(), 0.0001)
+	assert.InDelta(t, 1-325.0/361.0, m.WeightedFFI(), 0.0001)
+
+	assert.Equal(t, 0.0, make(Memory, 10).FFI(), "A single hole")
+	assert.Equal(t, 0.0, make(Memory, 10).WeightedFFI())
+	m = make(Memory, 10)
+	m.Allocate(&Process{ID: "full", SizeInKB: 10}, 0)
+	assert.Equal(t, 0.0, m.FFI())
+	assert.Equal(t, 0.0, m.WeightedFFI())
+}
+
+func TestAllocateMinFrag(t *testing.T) {
+	// Free segments: [0,10) and [12,20)
+	createMemory := func() (Memory, []*Process) {
+		m := make(Memory, 20)
+		m.Allocate(&Process{ID: "wall", SizeInKB: 2}, 10)
+		return m, []*Process{{ID: "eight", SizeInKB: 8}, {ID: "four", SizeInKB: 4}}
+	}
+
+	naive, ps := createMemory()
+	for _, p := range ps {
+		assert.NoError(t, naive.AllocateWorstFit(p))
+	}
+	assert.InDelta(t, 1-4.0/6.0, naive.FragmentationRatio(), 0.0001)
+
+	m, ps := createMemory()
+	placed, err := m.AllocateMinFrag(ps, FS_WORST_FIT)
+	assert.NoError(t, err)
+	assert.Len(t, placed, 2)
+	assert.Equal(t, "four", placed[0].ID)
+	assert.Equal(t, 0.0, m.FragmentationRatio())
+	assert.Equal(t, 0, ps[1].MemoryAddress)
+	assert.Equal(t, 12, ps[0].MemoryAddress)
+
+	m, ps = createMemory()
+	ps = append(ps, &Process{ID: "thirty", SizeInKB: 30})
+	placed, err = m.AllocateMinFrag(ps, FS_WORST_FIT)
+	assert.NoError(t, err)
+	assert.Len(t, placed, 2)
+	assert.False(t, ps[2].IsAllocated)
+
+	_, err = m.AllocateMinFrag([]*Process{nil}, FS_WORST_FIT)
+	assert.Error(t, err)
+}
+
+func TestPackBFD(t *testing.T) {
+	// Free segments: [0,8) and [9,14)
+	createMemory := func() (Memory, []*Process) {
+		m := make(Memory, 14)
+		m.Allocate(&Process{ID: "wall", SizeInKB: 1}, 8)
+		return m, []*Process{{ID: "four", SizeInKB: 4}, {ID: "five", SizeInKB: 5}, {ID: "four2", SizeInKB: 4}}
+	}
+
+	// FFD puts five in the first hole, leaving room for one of the fours only
+	m, ps := createMemory()
+	placed, unplaced := m.PackFFD(ps)
+	assert.Len(t, placed, 2)
+	assert.Equal(t, []*Process{ps[2]}, unplaced)
+	assert.Equal(t, 0, ps[1].MemoryAddress)
+
+	// BFD fills the small hole with five, leaving the large one for both fours
+	m, ps = createMemory()
+	placed, unplaced = m.PackBFD(ps)
+	assert.Equal(t, []*Process{ps[1], ps[0], ps[2]}, placed)
+	assert.Empty(t, unplaced)
+	assert.Equal(t, 9, ps[1].MemoryAddress)
+	assert.Equal(t, 0, ps[0].MemoryAddress)
+	assert.Equal(t, 4, ps[2].MemoryAddress)
+	assert.Equal(t, 0, m.TotalFree())
+}
+
+func TestWorstFitLast(t *testing.T) {
+	// Free segments: [0,5), [8,13) and [15,18)
+	m := make(Memory, 20)
+	m.Allocate(&Process{ID: "process3_", SizeInKB: 3}, 5)
+	m.Allocate(&Process{ID: "process2_", SizeInKB: 2}, 13)
+	m.Allocate(&Process{ID: "process2b", SizeInKB: 2}, 18)
+
+	start, size, err := m.WorstFit(4)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, start)
+	assert.Equal(t, 5, size)
+
+	start, size, err = m.WorstFitLast(4)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, start)
+	assert.Equal(t, 5, size)
+
+	start, _, err = m.Fit(4, FS_WORST_FIT_LAST)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, start)
+
+	_, _, err = m.WorstFitLast(6)
+	assert.EqualError(t, err, "There's not enough contiguous free space")
+}
+
+func TestCompactionPlan(t *testing.T) {
+	m := createAllocatedTestMemory()
+	plan := m.CompactionPlan()
+	assert.Len(t, plan, 5)
+	assert.Equal(t, "process2_", plan[0].Process.ID)
+	assert.Equal(t, 15, plan[0].From)
+	assert.Equal(t, 10, plan[0].To)
+	assert.Equal(t, 9, m.LargestFreeBlock(), "Planning should not modify memory")
+
+	assert.NoError(t, m.ApplyPlan(plan))
+	compacted := createAllocatedTestMemory()
+	compacted.Compact()
+	assert.Equal(t, compacted.Layout(), m.Layout())
+	for i := range m {
+		if compacted[i] != nil {
+			assert.Equal(t, compacted[i].MemoryAddress, m[i].MemoryAddress)
+		}
+	}
+
+	// Applying the same plan twice is invalid, since processes already moved
+	assert.Error(t, m.ApplyPlan(plan))
+	overlapping := createAllocatedTestMemory()
+	assert.Error(t, overlapping.ApplyPlan([]Relocation{{Process: overlapping[15], From: 15, To: 5}}))
+}
+
+func TestUsageByProcess(t *testing.T) {
+	m := make(Memory, 50)
+	p1 := &Process{ID: "process5_", SizeInKB: 5}
+	p2 := &Process{ID: "process12", SizeInKB: 12}
+	assert.NoError(t, m.AllocateWorstFit(p1))
+	assert.NoError(t, m.AllocateWorstFit(p2))
+
+	usage := m.UsageByProcess()
+	assert.Equal(t, map[string]int{"process5_": 5, "process12": 12}, usage)
+
+	// Corrupt memory: a cell of p2 gets overwritten by p1
+	m[p2.MemoryAddress] = p1
+	usage = m.UsageByProcess()
+	assert.NotEqual(t, p1.SizeInKB, usage[p1.ID])
+	assert.NotEqual(t, p2.SizeInKB, usage[p2.ID])
+}
+
+func TestFragmentationReport(t *testing.T) {
+	for _, m := range []Memory{createTestMemory(), createAllocatedTestMemory(), make(Memory, 10), {&Process{}}} {
+		report := m.FragmentationReport()
+		assert.Equal(t, m.TotalFree(), report.TotalFree)
+		assert.Equal(t, m.LargestFreeBlock(), report.LargestFreeBlock)
+		assert.Equal(t, m.HoleCount(), report.HoleCount)
+		assert.InDelta(t, m.MeanHoleSize(), report.MeanHoleSize, 0.0001)
+		assert.InDelta(t, m.FragmentationRatio(), report.FragmentationRatio, 0.0001)
+	}
+}
+
+func TestReserve(t *testing.T) {
+	m := make(Memory, 30)
+	assert.NoError(t, m.Reserve(10, 15))
+	assert.EqualError(t, m.Reserve(20, 5), "Cannot reserve -- space already occupied")
+	assert.Error(t, m.Reserve(25, 10))
+	assert.True(t, m.IsReserved(10))
+	assert.False(t, m.IsReserved(9))
+
+	assert.Equal(t, 15, m.TotalFree())
+	assert.Equal(t, RESERVED_BLOCK, m.Layout()[1].Name)
+	assert.Empty(t, m.UsageByProcess())
+
+	// The largest free region is [0,10): reserved cells are skipped
+	p := &Process{ID: "process8_", SizeInKB: 8}
+	assert.NoError(t, m.AllocateWorstFit(p))
+	assert.Equal(t, 0, p.MemoryAddress)
+	assert.Error(t, m.AllocateWorstFit(&Process{ID: "process6_", SizeInKB: 6}))
+	assert.Error(t, m.Allocate(&Process{ID: "process1_", SizeInKB: 1}, 12))
+
+	// Compaction never moves reserved cells
+	q := &Process{ID: "process3_", SizeInKB: 3}
+	assert.NoError(t, m.Allocate(q, 27))
+	_, err := m.Compact()
+	assert.NoError(t, err)
+	assert.Equal(t, 25, q.MemoryAddress)
+	for i := 10; i < 25; i++ {
+		assert.True(t, m.IsReserved(i))
+	}
+
+	m.Unreserve(0, 20)
+	assert.Equal(t, 14, m.TotalFree())
+	assert.True(t, m.IsReserved(20))
+	assert.Equal(t, p, m[0], "Unreserve should not free allocated cells")
+}
+
+func TestShiftLeft(t *testing.T) {
+	m := createAllocatedTestMemory()
+	assert.NoError(t, m.ShiftLeft(0))
+	assert.Equal(t, 10, m[10].MemoryAddress, "The block after the first gap should've moved")
+	assert.Equal(t, "process2_", m[10].ID)
+	assert.Nil(t, m[20])
+
+	shifts := 1
+	for m.ShiftLeft(0) == nil {
+		shifts++
+	}
+	assert.True(t, shifts >= 5)
+
+	compacted := createAllocatedTestMemory()
+	compacted.Compact()
+	assert.Equal(t, compacted.Layout(), m.Layout())
+	assert.Error(t, m.ShiftLeft(0))
+	assert.Error(t, m.ShiftLeft(-1))
+}
+
+func TestAllocationErrors(t *testing.T) {
+	m := make(Memory, 20)
+	occupier := &Process{ID: "occupier_", SizeInKB: 5}
+	assert.NoError(t, m.Allocate(occupier, 0))
+
+	cases := []struct {
+		err      error
+		expected error
+	}{
+		{m.Allocate(nil, 0), ErrNilProcess},
+		{m.AllocateWorstFit(nil), ErrNilProcess},
+		{m.Allocate(occupier, 10), ErrAlreadyAllocated},
+		{m.Allocate(&Process{ID: "process5_", SizeInKB: 5}, -1), ErrOutOfBounds},
+		{m.Allocate(&Process{ID: "process5_", SizeInKB: 5}, 18), ErrOutOfBounds},
+		{m.Allocate(&Process{ID: "process5_", SizeInKB: 5}, 3), ErrSpaceOccupied},
+		{m.Allocate(&Process{SizeInKB: 5}, 10), ErrMissingID},
+		{m.AllocateWorstFit(&Process{ID: "process50", SizeInKB: 50}), ErrNoContiguousSpace},
+		{m.AllocateFit(&Process{ID: "process50", SizeInKB: 50}, FS_FIRST_FIT), ErrNoContiguousSpace},
+	}
+	for i, c := range cases {
+		assert.True(t, errors.Is(c.err, c.expected), "Case %d: expected %v, got %v", i, c.expected, c.err)
+	}
+	assert.False(t, errors.Is(m.Allocate(nil, 0), ErrNoContiguousSpace))
+	assert.EqualError(t, m.Allocate(occupier, -1), "Cannot allocate -- process already in memory")
+}
+
+func TestFillWorstFit(t *testing.T) {
+	m := make(Memory, 30)
+	ps := []*Process{
+		{ID: "process10", SizeInKB: 10},
+		{ID: "process12", SizeInKB: 12},
+		{ID: "process9_", SizeInKB: 9},
+		{ID: "process3_", SizeInKB: 3},
+	}
+
+	placed := m.FillWorstFit(ps)
+	assert.Equal(t, ps[:2], placed)
+	assert.False(t, ps[2].IsAllocated)
+	assert.False(t, ps[3].IsAllocated, "Filling should stop at the first process that doesn't fit")
+
+	assert.Equal(t, 8, m.TotalFree())
+	assert.Equal(t, map[string]int{"process10": 10, "process12": 12}, m.UsageByProcess())
+	_, err := m.Compact()
+	assert.NoError(t, err, "Memory should be left consistent")
+}
+
+func TestAllocateOrCompact(t *testing.T) {
+	m := createAllocatedTestMemory()
+
+	p := &Process{ID: "process20", SizeInKB: 20}
+	compacted, err := m.AllocateOrCompact(p, FS_WORST_FIT)
+	assert.NoError(t, err)
+	assert.True(t, compacted)
+	assert.Equal(t, 72, p.MemoryAddress)
+	assert.Equal(t, 1, m.HoleCount())
+
+	small := &Process{ID: "process2_", SizeInKB: 2}
+	compacted, err = m.AllocateOrCompact(small, FS_FIRST_FIT)
+	assert.NoError(t, err)
+	assert.False(t, compacted, "There was no need to compact")
+
+	huge := &Process{ID: "process50", SizeInKB: 50}
+	compacted, err = m.AllocateOrCompact(huge, FS_WORST_FIT)
+	assert.True(t, errors.Is(err, ErrNoContiguousSpace))
+	assert.False(t, compacted, "Compacting can't make room for a process bigger than the total free memory")
+}
+
+func TestTotalOccupied(t *testing.T) {
+	m := createTestMemory()
+	assert.Equal(t, 72, m.TotalOccupied())
+
+	states := []Memory{m, make(Memory, 40), createAllocatedTestMemory()}
+	reservedMemory := make(Memory, 40)
+	reservedMemory.Reserve(5, 10)
+	reservedMemory.AllocateWorstFit(&Process{ID: "process7_", SizeInKB: 7})
+	states = append(states, reservedMemory)
+
+	for _, state := range states {
+		assert.Equal(t, len(state), state.TotalOccupied()+state.TotalFree())
+	}
+	assert.Equal(t, 17, reservedMemory.TotalOccupied())
+}
+
+func holeSizes(m Memory) []int {
+	sizes := []int{}
+	for _, block := range m.Layout() {
+		if block.Name == FREE_BLOCK {
+			sizes = append(sizes, block.Size)
+		}
+	}
+	return sizes
+}
+
+func TestAllocateBalanced(t *testing.T) {
+	// Holes: 5, 5, 2, 9, 7 (mean 5.6)
+	worst := createAllocatedTestMemory()
+	assert.NoError(t, worst.AllocateWorstFit(&Process{ID: "process2_", SizeInKB: 2}))
+	assert.Equal(t, []int{5, 5, 2, 7, 7}, holeSizes(worst))
+
+	balanced := createAllocatedTestMemory()
+	p := &Process{ID: "process2_", SizeInKB: 2}
+	assert.NoError(t, balanced.AllocateBalanced(p))
+	assert.Equal(t, 83, p.MemoryAddress, "Leaving 5 free cells is the closest to the mean hole size")
+	assert.Equal(t, []int{5, 5, 2, 9, 5}, holeSizes(balanced))
+
+	assert.True(t, errors.Is(balanced.AllocateBalanced(&Process{ID: "process10", SizeInKB: 10}), ErrNoContiguousSpace))
+}
+
+func TestAllocateMinLeftover(t *testing.T) {
+	// Holes: 5, 5, 2, 9, 7
+	m := createAllocatedTestMemory()
+	assert.Equal(t, ErrLeftoverTooSmall, m.AllocateMinLeftover(&Process{ID: "process10", SizeInKB: 8}, 2), "The 9 cells hole would leave a 1 cell sliver")
+	assert.Equal(t, []int{5, 5, 2, 9, 7}, holeSizes(m))
+	assert.Equal(t, ErrNoContiguousSpace, m.AllocateMinLeftover(&Process{ID: "process10", SizeInKB: 10}, 2))
+
+	p := &Process{ID: "process10", SizeInKB: 4}
+	assert.NoError(t, m.AllocateMinLeftover(p, 2))
+	assert.Equal(t, 52, p.MemoryAddress)
+
+	p = &Process{ID: "process11", SizeInKB: 5}
+	assert.NoError(t, m.AllocateMinLeftover(p, 3), "Holes that are filled exactly are valid")
+	assert.Equal(t, 10, p.MemoryAddress)
+	assert.Equal(t, []int{5, 2, 5, 7}, holeSizes(m))
+}
+
+func TestReallocate(t *testing.T) {
+	// Holes: 5, 5, 2, 9, 7
+	m := createAllocatedTestMemory()
+	p := m[43]
+	assert.NoError(t, m.Reallocate(p, 18), "Grows in place")
+	assert.Equal(t, 43, p.MemoryAddress)
+	assert.Equal(t, []int{5, 5, 2, 7}, holeSizes(m))
+
+	p = m[0]
+	assert.NoError(t, m.Reallocate(p, 12), "Grows over the hole after it")
+	assert.Equal(t, []int{3, 5, 2, 7}, holeSizes(m))
+
+	p = m[90]
+	assert.NoError(t, m.Reallocate(p, 12), "Moves to the largest hole, counting its own cells")
+	assert.Equal(t, 83, p.MemoryAddress)
+	assert.Equal(t, []int{3, 5, 2, 5}, holeSizes(m))
+
+	assert.True(t, errors.Is(m.Reallocate(p, 30), ErrNoContiguousSpace))
+	assert.Equal(t, 83, p.MemoryAddress)
+	assert.Equal(t, 12, p.SizeInKB)
+	assert.Equal(t, []int{3, 5, 2, 5}, holeSizes(m))
+
+	assert.NoError(t, m.Reallocate(p, 3), "Shrinks in place")
+	assert.Equal(t, []int{3, 5, 2, 14}, holeSizes(m))
+	assert.Error(t, m.Reallocate(&Process{ID: "process10", SizeInKB: 3}, 5))
+}
+
+func TestEachBlock(t *testing.T) {
+	m := createAllocatedTestMemory()
+	assert.NoError(t, m.Reserve(10, 2))
+	blocks := MemoryLayout{}
+	m.EachBlock(func(block MemoryBlock) bool {
+		blocks = append(blocks, &block)
+		return true
+	})
+	assert.Equal(t, 12, len(blocks))
+	assert.Equal(t, m.Layout(), blocks)
+	assert.Equal(t, RESERVED_BLOCK, blocks[1].Name)
+
+	visited := 0
+	m.EachBlock(func(block MemoryBlock) bool {
+		visited++
+		return block.Name != FREE_BLOCK
+	})
+	assert.Equal(t, 3, visited, "Should stop at the first hole")
+
+	make(Memory, 0).EachBlock(func(MemoryBlock) bool {
+		t.Error("An empty memory has no blocks")
+		return true
+	})
+}
+
+func TestHookedMemory(t *testing.T) {
+	hm := NewHookedMemory(createAllocatedTestMemory())
+	allocated, released := []string{}, []string{}
+	hm.OnAllocate(func(p *Process, start int) {
+		allocated = append(allocated, fmt.Sprintf("%s@%d", p.ID, start))
+	})
+	hm.OnRelease(func(p *Process, start int) {
+		released = append(released, fmt.Sprintf("%s@%d", p.ID, start))
+	})
+
+	p := &Process{ID: "process10", SizeInKB: 3}
+	assert.NoError(t, hm.Allocate(p, 10))
+	assert.NoError(t, hm.AllocateWorstFit(&Process{ID: "process11", SizeInKB: 4}))
+	assert.Error(t, hm.Allocate(&Process{ID: "process12", SizeInKB: 3}, 10))
+	assert.Equal(t, []string{"process10@10", "process11@52"}, allocated)
+
+	_, err := hm.ReleaseProcess(p)
+	assert.NoError(t, err)
+	_, err = hm.ReleaseProcess(hm.memory[0])
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"process10@10", "process1_@0"}, released)
+	assert.Equal(t, []int{15, 5, 2, 5, 7}, holeSizes(hm.memory))
+	assert.Equal(t, 34, hm.TotalFree())
+	assert.Equal(t, hm.memory.Layout(), hm.Layout())
+
+	released = released[:0]
+	_, err = hm.ReleaseProcess(nil)
+	assert.Equal(t, ErrNilProcess, err)
+	assert.Empty(t, released)
+}
+
+func TestCanFitAll(t *testing.T) {
+	// Holes: 5, 5, 2, 9, 7 (28 free cells)
+	m := createAllocatedTestMemory()
+	fitsAfterCompaction := []*Process{{ID: "process10", SizeInKB: 20}, {ID: "process11", SizeInKB: 8}}
+	assert.True(t, m.CanFitAll(fitsAfterCompaction))
+	assert.Equal(t, []int{5, 5, 2, 9, 7}, holeSizes(m), "Nothing should be placed")
+	assert.True(t, m.CanFitAll(nil))
+
+	assert.False(t, m.CanFitAll([]*Process{{ID: "process10", SizeInKB: 20}, {ID: "process11", SizeInKB: 9}}), "Exceeds the free memory")
+	assert.False(t, m.CanFitAll([]*Process{{ID: "process10", SizeInKB: 20}, nil}))
+
+	assert.NoError(t, m.Reserve(83, 1))
+	assert.False(t, m.CanFitAll([]*Process{{ID: "process10", SizeInKB: 22}}), "Compaction can't move reserved blocks")
+	assert.True(t, m.CanFitAll([]*Process{{ID: "process10", SizeInKB: 21}}))
+}
+
+func TestDumpAddresses(t *testing.T) {
+	m := createAllocatedTestMemory()
+	assert.NoError(t, m.Reserve(10, 2))
+	expected := "" +
+		"0x0000-0x0009  0001\n" +
+		"0x000A-0x000B  reserved\n" +
+		"0x000C-0x000E  free\n" +
+		"0x000F-0x0018  0002\n" +
+		"0x0019-0x001D  free\n" +
+		"0x001E-0x0028  0003\n" +
+		"0x0029-0x002A  free\n" +
+		"0x002B-0x0033  0004\n" +
+		"0x0034-0x003C  free\n" +
+		"0x003D-0x0052  0005\n" +
+		"0x0053-0x0059  free\n" +
+		"0x005A-0x0063  0006\n"
+	assert.Equal(t, expected, m.DumpAddresses())
+	assert.Equal(t, "", make(Memory, 0).DumpAddresses())
+}
+
+func TestPlace(t *testing.T) {
+	m := make(Memory, 30)
+	a, b, c := &Process{ID: "a", Name: "a", SizeInKB: 5}, &Process{ID: "b", Name: "b", SizeInKB: 10}, &Process{ID: "c", Name: "c", SizeInKB: 3}
+	assert.NoError(t, m.Place([]Placement{{a, 2}, {b, 7}, {c, 27}}))
+	assert.Equal(t, MemoryLayout{
+		{Start: 0, Size: 2, Name: FREE_BLOCK},
+		{Start: 2, Size: 5, Name: "a", OwnerID: "a"},
+		{Start: 7, Size: 10, Name: "b", OwnerID: "b"},
+		{Start: 17, Size: 10, Name: FREE_BLOCK},
+		{Start: 27, Size: 3, Name: "c", OwnerID: "c"},
+	}, m.Layout())
+
+	m = make(Memory, 30)
+	d := &Process{ID: "d", Name: "d", SizeInKB: 5}
+	assert.Equal(t, ErrSpaceOccupied, m.Place([]Placement{{&Process{ID: "e", SizeInKB: 5}, 0}, {d, 4}}))
+	assert.True(t, errors.Is(m.Place([]Placement{{d, 28}}), ErrOutOfBounds))
+	assert.Equal(t, ErrNilProcess, m.Place([]Placement{{nil, 0}}))
+	assert.Equal(t, 30, m.TotalFree(), "Failed placements shouldn't change memory")
+	assert.False(t, d.IsAllocated)
+}
+
+func TestLayoutOwnerID(t *testing.T) {
+	m := make(Memory, 10)
+	assert.NoError(t, m.Place([]Placement{{&Process{ID: "first", Name: "twin", SizeInKB: 3}, 0}, {&Process{ID: "second", Name: "twin", SizeInKB: 4}, 3}}))
+	m.Reserve(8, 2)
+
+	layout := m.Layout()
+	assert.Len(t, layout, 4)
+	assert.Equal(t, "twin", layout[0].Name)
+	assert.Equal(t, "twin", layout[1].Name)
+	assert.Equal(t, "first", layout[0].OwnerID)
+	assert.Equal(t, "second", layout[1].OwnerID)
+	assert.Equal(t, "", layout[2].OwnerID, "Free blocks have no owner")
+	assert.Equal(t, "", layout[3].OwnerID, "Reserved blocks have no owner")
+}
+
+func TestNeighborsOf(t *testing.T) {
+	// Holes: 5, 5, 2, 9, 7 at 10, 25, 41, 52 and 83
+	m := createAllocatedTestMemory()
+	left, right := m.NeighborsOf(m[15])
+	assert.Equal(t, 5, left)
+	assert.Equal(t, 5, right)
+
+	left, right = m.NeighborsOf(m[0])
+	assert.Equal(t, 0, left, "Bounded by the start of memory")
+	assert.Equal(t, 5, right)
+
+	left, right = m.NeighborsOf(m[90])
+	assert.Equal(t, 7, left)
+	assert.Equal(t, 0, right, "Bounded by the end of memory")
+
+	assert.NoError(t, m.Allocate(&Process{ID: "process10", SizeInKB: 5}, 10))
+	left, right = m.NeighborsOf(m[10])
+	assert.Equal(t, 0, left)
+	assert.Equal(t, 0, right)
+
+	left, right = m.NeighborsOf(&Process{ID: "process11", SizeInKB: 5})
+	assert.Equal(t, 0, left+right, "Processes out of memory have no neighbors")
+}
+
+func TestBitmap(t *testing.T) {
+	m := createAllocatedTestMemory()
+	m.Reserve(83, 1)
+	bitmap, cells := m.Bitmap()
+	assert.Equal(t, 100, cells)
+	assert.Len(t, bitmap, 13)
+	assert.Equal(t, byte(0xFF), bitmap[0])
+	assert.Equal(t, byte(0xC1), bitmap[1], "Cells 8 and 9 are occupied, 10 to 14 free, 15 occupied")
+
+	occupied := make([]bool, cells)
+	for i := range occupied {
+		occupied[i] = bitmap[i/8]&(0x80>>uint(i%8)) != 0
+	}
+	for i := range m {
+		assert.Equal(t, m[i] != nil, occupied[i], "Cell %d", i)
+	}
+
+	bitmap, cells = make(Memory, 0).Bitmap()
+	assert.Empty(t, bitmap)
+	assert.Equal(t, 0, cells)
+}
+
+func TestSetReservedTail(t *testing.T) {
+	m := make(Memory, 20)
+	assert.NoError(t, m.Allocate(&Process{ID: "process1_", SizeInKB: 5}, 0))
+	assert.NoError(t, m.SetReservedTail(10))
+	assert.Equal(t, 5, m.TotalFree())
+
+	// [5, 20) would be the largest free region, but its tail is reserved
+	p := &Process{ID: "process2_", SizeInKB: 3}
+	assert.NoError(t, m.AllocateWorstFit(p))
+	assert.Equal(t, 5, p.MemoryAddress)
+	for _, strategy := range []FitStrategy{FS_FIRST_FIT, FS_BEST_FIT, FS_WORST_FIT, FS_WORST_FIT_LAST, FS_BALANCED_FIT} {
+		assert.Equal(t, ErrNoContiguousSpace, m.AllocateFit(&Process{ID: "process3_", SizeInKB: 3}, strategy), string(strategy))
+	}
+
+	assert.NoError(t, m.SetReservedTail(4))
+	assert.Equal(t, 8, m.TotalFree())
+	assert.True(t, m.IsReserved(16))
+	assert.False(t, m.IsReserved(15))
+
+	m.ReleaseProcess(p)
+	assert.NoError(t, m.Allocate(&Process{ID: "process3_", SizeInKB: 3}, 13))
+	assert.Error(t, m.SetReservedTail(6), "The new tail overlaps a process")
+	assert.True(t, m.IsReserved(16), "A failed change keeps the old tail")
+	assert.Error(t, m.SetReservedTail(21))
+	assert.NoError(t, m.SetReservedTail(0))
+	assert.False(t, m.IsReserved(19))
+
+	// Cells reserved with Reserve outlive the changes of the tail
+	m = make(Memory, 100)
+	assert.NoError(t, m.Reserve(90, 10))
+	assert.NoError(t, m.SetReservedTail(5))
+	assert.Equal(t, 90, m.TotalFree())
+	assert.NoError(t, m.SetReservedTail(20))
+	assert.Equal(t, 80, m.TotalFree())
+	assert.NoError(t, m.SetReservedTail(0))
+	assert.Equal(t, 90, m.TotalFree())
+	for i := 90; i < 100; i++ {
+		assert.True(t, m.IsReserved(i), "Cell %d", i)
+	}
+
+	// The tail survives a snapshot
+	d := NewSeeded(20, 1)
+	assert.NoError(t, d.Memory.SetReservedTail(4))
+	clone := cloneDino(t, d)
+	assert.NoError(t, clone.Memory.SetReservedTail(2))
+	assert.Equal(t, 18, clone.Memory.TotalFree())
+}
+
+func TestTrimFreeTail(t *testing.T) {
+	m := make(Memory, 100)
+	p := &Process{ID: "p", SizeInKB: 10}
+	m.Allocate(p, 20)
+	assert.Equal(t, 70, m.TrimFreeTail())
+	trimmed := m.WithoutFreeTail()
+	assert.Len(t, trimmed, 30)
+	assert.Equal(t, 0, trimmed.TrimFreeTail())
+	assert.Equal(t, p, trimmed[29], "Occupied cells are kept")
+	assert.Equal(t, 20, trimmed.TotalFree())
+
+	m.Allocate(&Process{ID: "last", SizeInKB: 1}, 99)
+	assert.Equal(t, 0, m.TrimFreeTail())
+	assert.Len(t, m.WithoutFreeTail(), 100)
+
+	assert.Equal(t, 0, Memory{}.TrimFreeTail())
+	assert.Equal(t, 3, make(Memory, 3).TrimFreeTail())
+}
+
+func TestMemoryEqual(t *testing.T) {
+	m := createAllocatedTestMemory()
+	assert.True(t, m.Equal(createAllocatedTestMemory()), "Different processes with the same IDs")
+	assert.True(t, make(Memory, 0).Equal(nil))
+
+	assert.False(t, m.Equal(make(Memory, 99)))
+	assert.False(t, m.Equal(append(createAllocatedTestMemory(), nil)))
+
+	other := createAllocatedTestMemory()
+	other[12] = other[0]
+	assert.False(t, m.Equal(other), "A free cell is occupied in the other")
+	other = createAllocatedTestMemory()
+	other[0] = &Process{ID: "process10"}
+	assert.False(t, m.Equal(other), "A cell holds a different process")
+
+	d := NewSeeded(100, 1)
+	d.Step()
+	assert.True(t, d.Memory.Equal(cloneDino(t, d).Memory))
+}
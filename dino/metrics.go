@@ -0,0 +1,264 @@
+package dino
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Metrics accumulates statistics about a run, updated at the end of every step
+type Metrics struct {
+	Samples        int     // steps measured
+	UtilizationSum float64 // sum of the occupied memory percent of every step
+	ReadyLengthSum int     // sum of the processes left waiting in the ready queue at every step
+
+	// Steps the CPU was idle, by reason
+	IdleNothingReady  int // the ready queue was empty
+	IdleBlocked       int // every ready process was blocked
+	IdleWaitingIO     int // the head of the ready queue was waiting for an IO device
+	IdleContextSwitch int // the CPU was switching to another process
+
+	BandwidthStalls int // times a process dispatched to a device couldn't run for lack of MemoryBandwidth
+
+	CPUTime       map[string]int // process ID -> steps run on the CPU
+	AdmittedSizes map[int]int    // size in KB -> processes admitted with that size
+
+	Arrivals           int // random processes that arrived
+	AllocationAttempts int // times a process waiting for admission was tried
+	Allocations        int // times it could be allocated
+
+	Touches map[string]TouchStats // process ID -> cells touched while running
+
+	Compactions int // times memory was compacted with Dino.Compact
+}
+
+// TouchStats sums up the cells a process touched
+type TouchStats struct {
+	Last    int // offset of the last touched cell within the block of the process
+	Touches int
+	Near    int // touches at most one cell away from the previous one
+}
+
+// recordMetrics samples the state of the Dino at the end of a step
+func (d *Dino) recordMetrics() {
+	d.metrics.Samples++
+	if len(d.Memory) > 0 {
+		d.metrics.UtilizationSum += 100 * float64(len(d.Memory)-d.Memory.TotalFree()) / float64(len(d.Memory))
+	}
+}
+
+// Metrics returns the statistics gathered so far
+func (d *Dino) Metrics() Metrics {
+	return d.metrics
+}
+
+// AverageUtilization returns the mean percent of occupied memory over every step
+func (d *Dino) AverageUtilization() float64 {
+	if d.metrics.Samples == 0 {
+		return 0
+	}
+	return d.metrics.UtilizationSum / float64(d.metrics.Samples)
+}
+
+// AverageReadyQueueLength returns the mean number of processes left waiting in the ready queue over every step, those
+// whose WaitTime grew. By Little's law it matches the rate processes are admitted at times their average wait
+func (d *Dino) AverageReadyQueueLength() float64 {
+	if d.metrics.Samples == 0 {
+		return 0
+	}
+	return float64(d.metrics.ReadyLengthSum) / float64(d.metrics.Samples)
+}
+
+// CPUIdleSteps returns how many steps no process was dispatched
+func (d *Dino) CPUIdleSteps() int {
+	return d.metrics.IdleNothingReady + d.metrics.IdleBlocked + d.metrics.IdleWaitingIO + d.metrics.IdleContextSwitch
+}
+
+// CPUIdlePercent returns the percent of steps where no process was dispatched
+func (d *Dino) CPUIdlePercent() float64 {
+	if d.metrics.Samples == 0 {
+		return 0
+	}
+	return 100 * float64(d.CPUIdleSteps()) / float64(d.metrics.Samples)
+}
+
+// CPUTimeByProcess returns how many steps each process has run on the CPU, by process ID
+func (d *Dino) CPUTimeByProcess() map[string]int {
+	cpuTime := make(map[string]int, len(d.metrics.CPUTime))
+	for id, steps := range d.metrics.CPUTime {
+		cpuTime[id] = steps
+	}
+	return cpuTime
+}
+
+// HottestProcess returns the process, running or terminated, that has run the most steps on the CPU. Ties are
+// broken by the lowest ID, and nil is returned if nothing has run yet
+func (d *Dino) HottestProcess() *Process {
+	var hottest *Process
+	for _, p := range append(d.processes(), d.terminated...) {
+		steps := d.metrics.CPUTime[p.ID]
+		if steps == 0 {
+			continue
+		} else if hottest == nil || steps > d.metrics.CPUTime[hottest.ID] || (steps == d.metrics.CPUTime[hottest.ID] && p.ID < hottest.ID) {
+			hottest = p
+		}
+	}
+	return hottest
+}
+
+// FairnessIndex returns Jain's fairness index over the CPU time of every admitted process, running or terminated:
+// (Σx)² / (n·Σx²). It's 1 when they all ran the same, down to 1/n when a single one did. 0 if nothing has run yet
+func (d *Dino) FairnessIndex() float64 {
+	n, sum, squares := 0, 0.0, 0.0
+	for _, p := range append(d.processes(), d.terminated...) {
+		if !p.IsAllocated && p.FinishStep == 0 {
+			continue // still waiting to be admitted
+		}
+		steps := float64(d.metrics.CPUTime[p.ID])
+		n++
+		sum += steps
+		squares += steps * steps
+	}
+	if squares == 0 {
+		return 0
+	}
+	return sum * sum / (float64(n) * squares)
+}
+
+// AdmittedSizeHistogram returns how many processes of each size, in KB, have been admitted
+func (d *Dino) AdmittedSizeHistogram() map[int]int {
+	histogram := make(map[int]int, len(d.metrics.AdmittedSizes))
+	for size, count := range d.metrics.AdmittedSizes {
+		histogram[size] = count
+	}
+	return histogram
+}
+
+// AllocationSuccessRate returns the fraction of admission attempts where the process could be allocated, 0 if none
+// were attempted
+func (d *Dino) AllocationSuccessRate() float64 {
+	if d.metrics.AllocationAttempts == 0 {
+		return 0
+	}
+	return float64(d.metrics.Allocations) / float64(d.metrics.AllocationAttempts)
+}
+
+// LocalityScore returns the fraction of the touches of p that were at most one cell away from the previous one, from
+// 0 (no spatial locality) to 1. Processes that have touched less than two cells score 0
+func (d *Dino) LocalityScore(p *Process) float64 {
+	stats := d.metrics.Touches[p.ID]
+	if stats.Touches < 2 {
+		return 0
+	}
+	return float64(stats.Near) / float64(stats.Touches-1)
+}
+
+// touchCell touches a cell of the block of p following its TouchPattern
+func (d *Dino) touchCell(p *Process) {
+	if p.TouchPattern == TP_NONE || p.SizeInKB < 1 {
+		return
+	}
+	stats, touched := d.metrics.Touches[p.ID]
+	offset := 0
+	switch p.TouchPattern {
+	case TP_SEQUENTIAL:
+		if touched {
+			offset = (stats.Last + 1) % p.SizeInKB
+		}
+	case TP_RANDOM:
+		offset = d.touchRand.Intn(p.SizeInKB)
+	}
+
+	if touched && offset-stats.Last <= 1 && stats.Last-offset <= 1 {
+		stats.Near++
+	}
+	stats.Last = offset
+	stats.Touches++
+	if d.metrics.Touches == nil {
+		d.metrics.Touches = map[string]TouchStats{}
+	}
+	d.metrics.Touches[p.ID] = stats
+}
+
+// Compact compacts the memory of the Dino between steps, counting it in the metrics
+func (d *Dino) Compact() error {
+	if _, err := d.Memory.Compact(); err != nil {
+		return err
+	}
+	d.metrics.Compactions++
+	d.refreshState()
+	return nil
+}
+
+// Summary returns a human-readable report of the run so far
+func (d *Dino) Summary() string {
+	var wait, turnaround, response, throughput float64
+	if completed := float64(len(d.terminated)); completed > 0 {
+		for _, p := range d.terminated {
+			wait += float64(p.WaitTime)
+			turnaround += float64(p.Turnaround())
+			response += float64(p.ResponseTime())
+		}
+		wait, turnaround, response = wait/completed, turnaround/completed, response/completed
+	}
+	if d.step > 0 {
+		throughput = float64(len(d.terminated)) / float64(d.step)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Steps:               %d\n", d.step)
+	fmt.Fprintf(&b, "Processes completed: %d\n", len(d.terminated))
+	fmt.Fprintf(&b, "Average wait:        %.2f steps\n", wait)
+	fmt.Fprintf(&b, "Average turnaround:  %.2f steps\n", turnaround)
+	fmt.Fprintf(&b, "Average response:    %.2f steps\n", response)
+	fmt.Fprintf(&b, "Throughput:          %.3f processes/step\n", throughput)
+	fmt.Fprintf(&b, "CPU idle:            %.1f%%\n", d.CPUIdlePercent())
+	fmt.Fprintf(&b, "Average utilization: %.1f%%\n", d.AverageUtilization())
+	fmt.Fprintf(&b, "Fragmentation:       %.2f\n", d.Memory.FragmentationRatio())
+	fmt.Fprintf(&b, "Compactions:         %d\n", d.metrics.Compactions)
+	return b.String()
+}
+
+// WaitTimePercentile returns the p-th percentile (0-100) of the wait time of terminated processes
+func (d *Dino) WaitTimePercentile(p float64) int {
+	return d.terminatedPercentile(p, func(process *Process) int { return process.WaitTime })
+}
+
+// WaitTimeHistogram counts the terminated processes by wait time, in buckets of bucketSize steps keyed by their lower
+// bound: with a bucket size of 5, a process that waited 7 steps counts in bucket 5. Bucket sizes below 1 count as 1
+func (d *Dino) WaitTimeHistogram(bucketSize int) map[int]int {
+	if bucketSize < 1 {
+		bucketSize = 1
+	}
+	histogram := map[int]int{}
+	for _, p := range d.terminated {
+		histogram[p.WaitTime/bucketSize*bucketSize]++
+	}
+	return histogram
+}
+
+// TurnaroundPercentile returns the p-th percentile (0-100) of the turnaround of terminated processes
+func (d *Dino) TurnaroundPercentile(p float64) int {
+	return d.terminatedPercentile(p, (*Process).Turnaround)
+}
+
+// terminatedPercentile computes a nearest-rank percentile of value over the terminated processes, 0 if there are none
+func (d *Dino) terminatedPercentile(p float64, value func(*Process) int) int {
+	if len(d.terminated) == 0 {
+		return 0
+	}
+	values := []int{}
+	for _, process := range d.terminated {
+		values = append(values, value(process))
+	}
+	sort.Ints(values)
+
+	rank := int(math.Ceil(p / 100 * float64(len(values))))
+	if rank < 1 {
+		rank = 1
+	} else if rank > len(values) {
+		rank = len(values)
+	}
+	return values[rank-1]
+}
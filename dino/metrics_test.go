@@ -0,0 +1,346 @@
+package dino
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestSummary(t *testing.T) {
+	d := NewSeeded(100, 7)
+	for i := 0; i < 40; i++ {
+		d.Step()
+	}
+	assert.NoError(t, d.Compact())
+	for i := 0; i < 10; i++ {
+		d.Step()
+	}
+
+	golden := filepath.Join("testdata", "summary.golden")
+	if *update {
+		assert.NoError(t, os.WriteFile(golden, []byte(d.Summary()), 0644))
+	}
+	expected, err := os.ReadFile(golden)
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), d.Summary())
+}
+
+func TestAverageUtilization(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	assert.Equal(t, 0.0, d.AverageUtilization())
+
+	// Admitted on the first step, terminated on the second
+	p := &Process{ID: "half", Name: "half", Type: PT_INTERACTIVE, SizeInKB: 50, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1}
+	d.Inject(p)
+	d.Step()
+	assert.InDelta(t, 50.0, d.AverageUtilization(), 0.0001)
+	d.Step()
+	assert.InDelta(t, 25.0, d.AverageUtilization(), 0.0001)
+	assert.Equal(t, 2, d.Metrics().Samples)
+
+	random := NewSeeded(200, 5)
+	for i := 0; i < 50; i++ {
+		random.Step()
+	}
+	assert.True(t, random.AverageUtilization() > 30 && random.AverageUtilization() <= 100, "Utilization was %f", random.AverageUtilization())
+}
+
+func TestCPUIdle(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	d.ContextSwitchSteps = 1
+	assert.Equal(t, 0.0, d.CPUIdlePercent())
+
+	a := &Process{ID: "a", Name: "a", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1}
+	b := &Process{ID: "b", Name: "b", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1}
+	d.Inject(a)
+	d.Step() // the first dispatch has nothing to switch from
+	d.Step() // a terminates
+	d.Step() // nothing is ready
+	assert.Equal(t, 1, d.CPUIdleSteps())
+	assert.Equal(t, 1, d.Metrics().IdleNothingReady)
+
+	d.Inject(b)
+	result, _ := d.StepDetailed() // switching from a to b
+	assert.Empty(t, result.DispatchedToCPU)
+	assert.Equal(t, 1, d.Metrics().IdleContextSwitch)
+
+	clone := cloneDino(t, d)
+	result, _ = d.StepDetailed()
+	assert.Equal(t, Processes{b}, result.DispatchedToCPU)
+	clone.Step()
+	assertSameDino(t, d, clone)
+
+	d.Step() // b terminates
+	assert.Equal(t, 2, d.CPUIdleSteps())
+	assert.Equal(t, 0, d.Metrics().IdleBlocked)
+	assert.InDelta(t, 100.0/3, d.CPUIdlePercent(), 0.0001)
+
+	replayed, err := Replay(d.Events(), d.MemorySize())
+	assert.NoError(t, err)
+	assert.Equal(t, d.Memory.Layout(), replayed.Memory.Layout())
+}
+
+func TestHottestProcess(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	assert.Nil(t, d.HottestProcess())
+
+	light := &Process{ID: "light", Name: "light", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1}
+	heavy := &Process{ID: "heavy", Name: "heavy", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1}
+	io := &Process{ID: "io", Name: "io", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_IO, BT_IO, BT_IO, BT_IO, BT_IO, BT_IO, BT_IO, BT_IO, BT_IO, BT_IO}, MemoryAddress: -1}
+	d.Inject(light)
+	d.Inject(heavy)
+	d.Inject(io)
+	for i := 0; i < 15; i++ {
+		d.Step()
+	}
+
+	cpuTime := d.CPUTimeByProcess()
+	assert.Equal(t, 2, cpuTime["light"])
+	assert.True(t, cpuTime["heavy"] > cpuTime["light"])
+	assert.Equal(t, 0, cpuTime["io"], "IO bursts don't count as CPU time")
+	assert.Equal(t, heavy, d.HottestProcess())
+
+	cpuTime["heavy"] = 0
+	assert.Equal(t, heavy, d.HottestProcess(), "CPUTimeByProcess should return a copy")
+	assert.Equal(t, "heavy", cloneDino(t, d).HottestProcess().ID)
+}
+
+func TestPercentiles(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	assert.Equal(t, 0, d.WaitTimePercentile(50))
+
+	// Round robin: each process runs twice, so they wait 3, 4, 5 and 6 steps, and terminate after 5, 6, 7 and 8
+	for _, id := range []string{"a", "b", "c", "d"} {
+		d.Inject(&Process{ID: id, Name: id, Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1})
+	}
+	for i := 0; i < 8; i++ {
+		d.Step()
+	}
+	assert.Len(t, d.terminated, 4)
+
+	assert.Equal(t, 3, d.WaitTimePercentile(0))
+	assert.Equal(t, 4, d.WaitTimePercentile(50))
+	assert.Equal(t, 6, d.WaitTimePercentile(95))
+	assert.Equal(t, 6, d.TurnaroundPercentile(50))
+	assert.Equal(t, 8, d.TurnaroundPercentile(95))
+	assert.Equal(t, 8, d.TurnaroundPercentile(100))
+}
+
+func TestAverageReadyQueueLength(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	assert.Equal(t, 0.0, d.AverageReadyQueueLength())
+
+	// Same as TestPercentiles: 3 + 4 + 5 + 6 waiting steps over 8 steps
+	for _, id := range []string{"a", "b", "c", "d"} {
+		d.Inject(&Process{ID: id, Name: id, Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1})
+	}
+	for i := 0; i < 8; i++ {
+		d.Step()
+	}
+	assert.Equal(t, 18.0/8.0, d.AverageReadyQueueLength())
+}
+
+func TestLittlesLaw(t *testing.T) {
+	d := NewSeeded(5000, 9)
+	d.SetArrivalRate(0.25)
+	const steps = 3000
+	for i := 0; i < steps; i++ {
+		d.Step()
+	}
+
+	// Memory is large enough for every process to be admitted right away, so they arrive at the ready queue at the
+	// rate they arrive at the simulator
+	lambda := float64(d.Metrics().Arrivals) / steps
+	wait := 0.0
+	for _, p := range d.terminated {
+		wait += float64(p.WaitTime)
+	}
+	wait /= float64(len(d.terminated))
+	assert.InDelta(t, 1.0, d.AverageReadyQueueLength()/(lambda*wait), 0.1)
+}
+
+func TestWaitTimeHistogram(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	assert.Empty(t, d.WaitTimeHistogram(5))
+
+	// Same as TestPercentiles, they wait 3, 4, 5 and 6 steps
+	for _, id := range []string{"a", "b", "c", "d"} {
+		d.Inject(&Process{ID: id, Name: id, Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1})
+	}
+	for i := 0; i < 8; i++ {
+		d.Step()
+	}
+
+	assert.Equal(t, map[int]int{0: 2, 5: 2}, d.WaitTimeHistogram(5))
+	assert.Equal(t, map[int]int{2: 1, 4: 2, 6: 1}, d.WaitTimeHistogram(2))
+	assert.Equal(t, map[int]int{3: 1, 4: 1, 5: 1, 6: 1}, d.WaitTimeHistogram(0))
+}
+
+func TestAdmittedSizeHistogram(t *testing.T) {
+	d := NewSeeded(30, 1)
+	d.DisableArrivals = true
+	assert.Empty(t, d.AdmittedSizeHistogram())
+
+	for i, size := range []int{10, 5, 10, 20} {
+		id := string(rune('a' + i))
+		d.Inject(&Process{ID: id, Name: id, Type: PT_INTERACTIVE, SizeInKB: size, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1})
+	}
+	d.Step()
+	assert.Equal(t, map[int]int{10: 2, 5: 1}, d.AdmittedSizeHistogram(), "The 20KB process doesn't fit yet")
+
+	d.StepUntilIdle(20)
+	assert.Equal(t, map[int]int{10: 2, 5: 1, 20: 1}, d.AdmittedSizeHistogram())
+}
+
+// fcfs runs every process to completion in arrival order, unlike the default ready queue that requeues them behind
+type fcfs struct {
+	*ProcessHeap
+}
+
+func newFCFS() fcfs {
+	return fcfs{NewProcessHeap(func(a, b *Process) bool {
+		return a.ArrivalStep < b.ArrivalStep || (a.ArrivalStep == b.ArrivalStep && a.ID < b.ID)
+	})}
+}
+func (s fcfs) Add(p *Process) error {
+	s.Push(p)
+	return nil
+}
+func (s fcfs) Get() (*Process, error) {
+	if p := s.Pop(); p != nil {
+		return p, nil
+	}
+	return nil, errors.New("Empty queue")
+}
+func (s fcfs) Read() (*Process, error) {
+	if p := s.Peek(); p != nil {
+		return p, nil
+	}
+	return nil, errors.New("Empty queue")
+}
+func (s fcfs) Name() string {
+	return "FCFS"
+}
+func (s fcfs) String() []string {
+	return nil
+}
+
+func TestFairnessIndex(t *testing.T) {
+	run := func(ready Scheduler) *Dino {
+		d := NewSeeded(100, 1)
+		d.DisableArrivals = true
+		if ready != nil {
+			assert.NoError(t, d.SetScheduler(ready))
+		}
+		assert.Equal(t, 0.0, d.FairnessIndex(), "Nothing ran yet")
+		for i, n := range []int{40, 30, 20} {
+			bursts := make(Bursts, n)
+			for j := range bursts {
+				bursts[j] = BT_CPU
+			}
+			id := string(rune('a' + i))
+			d.Inject(&Process{ID: id, Name: id, Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: bursts, MemoryAddress: -1})
+		}
+		for i := 0; i < 12; i++ {
+			d.Step()
+		}
+		return d
+	}
+
+	roundRobin := run(nil)
+	firstComeFirstServed := run(newFCFS())
+	assert.Equal(t, map[string]int{"a": 12}, firstComeFirstServed.CPUTimeByProcess())
+	assert.InDelta(t, 1, roundRobin.FairnessIndex(), 1e-9)
+	assert.InDelta(t, 1.0/3, firstComeFirstServed.FairnessIndex(), 1e-9)
+}
+
+func TestAllocationSuccessRate(t *testing.T) {
+	d := NewSeeded(30, 1)
+	d.DisableArrivals = true
+	assert.Equal(t, 0.0, d.AllocationSuccessRate())
+
+	for _, id := range []string{"a", "b"} {
+		d.Inject(&Process{ID: id, Name: id, Type: PT_INTERACTIVE, SizeInKB: 20, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1})
+	}
+	d.Step() // a is allocated, b doesn't fit
+	assert.Equal(t, 2, d.Metrics().AllocationAttempts)
+	assert.Equal(t, 0.5, d.AllocationSuccessRate())
+
+	d.Step() // b still doesn't fit, a terminates
+	d.Step() // b is allocated
+	assert.Equal(t, 4, d.Metrics().AllocationAttempts)
+	assert.Equal(t, 2, d.Metrics().Allocations)
+	assert.Equal(t, 0.5, d.AllocationSuccessRate())
+
+	overcommitted := NewSeeded(100, 3)
+	for i := 0; i < 50; i++ {
+		before := overcommitted.Metrics()
+		overcommitted.Step()
+		after := overcommitted.Metrics()
+		failed := (after.AllocationAttempts - after.Allocations) - (before.AllocationAttempts - before.Allocations)
+		assert.True(t, failed <= 1, "Step %d: the blocked head of the new queue counted %d times", i+1, failed)
+	}
+	metrics := overcommitted.Metrics()
+	assert.True(t, overcommitted.AllocationSuccessRate() < 1)
+	assert.Equal(t, float64(metrics.Allocations)/float64(metrics.AllocationAttempts), overcommitted.AllocationSuccessRate())
+	assert.Equal(t, len(overcommitted.terminated)+len(overcommitted.readyQueue.Processes()), metrics.Allocations)
+}
+
+func TestLocalityScore(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	long := Bursts{}
+	for i := 0; i < 40; i++ {
+		long = append(long, BT_CPU)
+	}
+	sequential := &Process{ID: "seq", Name: "seq", Type: PT_INTERACTIVE, SizeInKB: 30, Bursts: long, MemoryAddress: -1, TouchPattern: TP_SEQUENTIAL}
+	random := &Process{ID: "rnd", Name: "rnd", Type: PT_INTERACTIVE, SizeInKB: 30, Bursts: append(Bursts{}, long...), MemoryAddress: -1, TouchPattern: TP_RANDOM}
+	untouched := &Process{ID: "none", Name: "none", Type: PT_INTERACTIVE, SizeInKB: 30, Bursts: append(Bursts{}, long...), MemoryAddress: -1}
+	d.Inject(sequential)
+	d.Inject(random)
+	d.Inject(untouched)
+	for i := 0; i < 30; i++ {
+		d.Step()
+	}
+
+	assert.Equal(t, 10, d.Metrics().Touches["seq"].Touches)
+	assert.Equal(t, 1.0, d.LocalityScore(sequential))
+	assert.True(t, d.LocalityScore(random) < d.LocalityScore(sequential), "Random locality was %f", d.LocalityScore(random))
+	assert.Equal(t, 0.0, d.LocalityScore(untouched))
+	assert.Equal(t, d.LocalityScore(random), cloneDino(t, d).LocalityScore(random))
+}
+
+func TestRandomTouchesKeepWorkload(t *testing.T) {
+	run := func(pattern TouchPattern) *Dino {
+		d := NewSeeded(100, 7)
+		bursts := Bursts{}
+		for i := 0; i < 40; i++ {
+			bursts = append(bursts, BT_CPU)
+		}
+		d.Inject(&Process{ID: "touchy", Name: "touchy", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: bursts, MemoryAddress: -1, TouchPattern: pattern})
+		for i := 0; i < 50; i++ {
+			d.Step()
+		}
+		return d
+	}
+
+	touched, untouched := run(TP_RANDOM), run(TP_NONE)
+	assert.True(t, len(touched.Metrics().Touches) > 0)
+	assert.Equal(t, untouched.source.draws, touched.source.draws)
+	assert.Equal(t, untouched.Metrics().Allocations, touched.Metrics().Allocations)
+
+	clone := cloneDino(t, touched)
+	assert.Equal(t, touched.touches.draws, clone.touches.draws)
+}
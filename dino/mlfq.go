@@ -0,0 +1,151 @@
+package dino
+
+import (
+	"errors"
+	"fmt"
+)
+
+type mlfqEntry struct {
+	process *Process
+	level   int
+	used    int // steps run at the current level
+}
+
+// MLFQScheduler is a multilevel feedback queue. Processes start at the top level (0), and are demoted to the next one
+// after running for the quantum of their level. Lower levels only run when every level above them is empty
+type MLFQScheduler struct {
+	name       string
+	levels     [][]mlfqEntry
+	quanta     []int
+	dispatched map[string]mlfqEntry // processes taken with Get, until they're added back
+	reason     string
+}
+
+// NewMLFQScheduler creates a scheduler with one level per quantum, the first being the top priority
+func NewMLFQScheduler(name string, quanta ...int) *MLFQScheduler {
+	return &MLFQScheduler{
+		name:       name,
+		levels:     make([][]mlfqEntry, len(quanta)),
+		quanta:     append([]int{}, quanta...),
+		dispatched: map[string]mlfqEntry{},
+	}
+}
+
+// SetQuantum changes the quantum of a level. Processes at that level are demoted according to the new quantum the
+// next time they're added back
+func (s *MLFQScheduler) SetQuantum(level, quantum int) error {
+	if level < 0 || level >= len(s.quanta) {
+		return fmt.Errorf("Cannot set quantum -- there's no level %d", level)
+	} else if quantum < 1 {
+		return errors.New("Cannot set quantum -- quantum should be positive")
+	}
+	s.quanta[level] = quantum
+	return nil
+}
+
+func (s *MLFQScheduler) Quantum(level int) int {
+	return s.quanta[level]
+}
+
+// QueueLength returns how many processes are waiting at a level
+func (s *MLFQScheduler) QueueLength(level int) int {
+	if level < 0 || level >= len(s.levels) {
+		return 0
+	}
+	return len(s.levels[level])
+}
+
+// Add queues a new process at the top level, or a dispatched one at its level (or the next, if it used its quantum)
+func (s *MLFQScheduler) Add(p *Process) error {
+	if p == nil {
+		return errors.New("Cannot add nil process")
+	} else if len(s.levels) == 0 {
+		return errors.New("Cannot add -- the scheduler has no levels")
+	}
+
+	e, ok := s.dispatched[p.ID]
+	if ok {
+		delete(s.dispatched, p.ID)
+		e.used++
+		if e.used >= s.quanta[e.level] && e.level < len(s.levels)-1 {
+			e.level++
+			e.used = 0
+		}
+	} else {
+		e = mlfqEntry{process: p}
+	}
+	s.levels[e.level] = append(s.levels[e.level], e)
+	return nil
+}
+
+func (s *MLFQScheduler) Get() (*Process, error) {
+	for level := range s.levels {
+		if len(s.levels[level]) != 0 {
+			e := s.levels[level][0]
+			s.levels[level] = s.levels[level][1:]
+			s.dispatched[e.process.ID] = e
+			s.reason = fmt.Sprintf("level %d, the highest with processes", level)
+			return e.process, nil
+		}
+	}
+	return nil, errors.New("Nothing to return")
+}
+
+// Remove drops p from its level, or forgets it if it's dispatched, leaving the other processes where they are
+func (s *MLFQScheduler) Remove(p *Process) bool {
+	delete(s.dispatched, p.ID)
+	for level := range s.levels {
+		for i, e := range s.levels[level] {
+			if e.process == p {
+				s.levels[level] = append(s.levels[level][:i:i], s.levels[level][i+1:]...)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *MLFQScheduler) Reason() string {
+	return s.reason
+}
+
+func (s *MLFQScheduler) Read() (*Process, error) {
+	for level := range s.levels {
+		if len(s.levels[level]) != 0 {
+			return s.levels[level][0].process, nil
+		}
+	}
+	return nil, errors.New("Nothing to return")
+}
+
+func (s *MLFQScheduler) Len() int {
+	length := 0
+	for level := range s.levels {
+		length += len(s.levels[level])
+	}
+	return length
+}
+
+func (s *MLFQScheduler) Name() string {
+	return s.name
+}
+
+func (s *MLFQScheduler) Processes() Processes {
+	processes := Processes{}
+	for level := range s.levels {
+		for _, e := range s.levels[level] {
+			processes = append(processes, e.process)
+		}
+	}
+	return processes
+}
+
+func (s *MLFQScheduler) String() []string {
+	stringSlice := []string{}
+	for level := range s.levels {
+		for _, e := range s.levels[level] {
+			stringSlice = append(stringSlice, fmt.Sprintf(" ['%s', %2d, %2dKB] L%d ", e.process.Name, e.process.RemainingBursts(), e.process.SizeInKB, level))
+		}
+	}
+	return stringSlice
+}
@@ -0,0 +1,106 @@
+package dino
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stepsBeforeDemotion runs p alone in s until it leaves the top level
+func stepsBeforeDemotion(s *MLFQScheduler, p *Process) int {
+	s.Add(p)
+	steps := 0
+	for s.QueueLength(0) == 1 && steps < 100 {
+		s.Get()
+		s.Add(p)
+		steps++
+	}
+	s.Get()
+	return steps
+}
+
+func TestMLFQScheduler(t *testing.T) {
+	s := NewMLFQScheduler("MLFQ", 2, 4)
+	first := testProcess()
+	assert.Equal(t, 2, stepsBeforeDemotion(s, first))
+	assert.Equal(t, 0, s.Len())
+
+	// A newcomer at the top level runs before a demoted process
+	s.Add(first)
+	newcomer := testProcess()
+	s.Add(newcomer)
+	assert.Equal(t, 1, s.QueueLength(0))
+	assert.Equal(t, 1, s.QueueLength(1))
+	p, err := s.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, newcomer, p)
+	p, _ = s.Get()
+	assert.Equal(t, first, p)
+	_, err = s.Get()
+	assert.Error(t, err)
+}
+
+func TestMLFQSetQuantum(t *testing.T) {
+	s := NewMLFQScheduler("MLFQ", 2, 4)
+	assert.Equal(t, 2, stepsBeforeDemotion(s, testProcess()))
+
+	assert.NoError(t, s.SetQuantum(0, 5))
+	assert.Equal(t, 5, s.Quantum(0))
+	assert.Equal(t, 5, stepsBeforeDemotion(s, testProcess()))
+
+	assert.Error(t, s.SetQuantum(2, 1))
+	assert.Error(t, s.SetQuantum(0, 0))
+	assert.Equal(t, 0, s.QueueLength(7))
+}
+
+func TestMLFQRemove(t *testing.T) {
+	s := NewMLFQScheduler("MLFQ", 2, 4)
+	a, b, c := testProcess(), testProcess(), testProcess()
+	s.Add(a)
+	s.Add(b)
+	s.Add(c)
+
+	assert.True(t, s.Remove(a))
+	assert.True(t, s.Remove(c))
+	assert.False(t, s.Remove(c))
+	assert.Equal(t, Processes{b}, s.Processes())
+	assert.Equal(t, 1, s.QueueLength(0), "Removing others doesn't count against the quantum of b")
+
+	// A dispatched process is forgotten, so it comes back as a newcomer
+	p, _ := s.Get()
+	s.Add(p)
+	p, _ = s.Get()
+	assert.False(t, s.Remove(p))
+	assert.Empty(t, s.dispatched)
+	s.Add(p)
+	assert.Equal(t, 1, s.QueueLength(0))
+}
+
+func TestTerminateProcessMLFQ(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	s := NewMLFQScheduler("MLFQ", 2, 4)
+	assert.NoError(t, d.SetScheduler(s))
+	for _, id := range []string{"a", "b", "c"} {
+		long := Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU}
+		assert.NoError(t, d.Inject(&Process{ID: id, Name: id, Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: long, MemoryAddress: -1}))
+	}
+	d.Step() // a runs, b and c wait at the top level
+
+	assert.NoError(t, d.TerminateProcess("b"))
+	assert.NoError(t, d.TerminateProcess("a"))
+	assert.Equal(t, 1, s.QueueLength(0), "c stays at the top level")
+	assert.Empty(t, s.dispatched)
+
+	// Without Remove, killing a queued process fails instead of draining the scheduler
+	d = NewSeeded(100, 1)
+	d.DisableArrivals = true
+	assert.NoError(t, d.SetScheduler(struct{ Scheduler }{NewMLFQScheduler("MLFQ", 2, 4)}))
+	for _, id := range []string{"a", "b"} {
+		d.Inject(&Process{ID: id, Name: id, Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1})
+	}
+	d.Step()
+	assert.EqualError(t, d.TerminateProcess("b"), "Cannot terminate -- scheduler 'MLFQ' can't drop queued processes")
+	_, location, _ := d.ProcessByID("b")
+	assert.Equal(t, "Ready", location)
+}
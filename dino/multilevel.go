@@ -2,6 +2,7 @@ package dino
 
 import (
 	"errors"
+	"fmt"
 	"github.com/stretchr/stew/slice"
 )
 
@@ -10,6 +11,7 @@ type MultilevelQueue struct {
 	Scheduler
 	name   string
 	queues []Scheduler // We assume that this array is ordered by priority, with 0-index being the top priority queue
+	last   Scheduler   // queue of the last process returned by Get
 }
 
 func (m *MultilevelQueue) Name() string {
@@ -25,12 +27,24 @@ func (m *MultilevelQueue) Get() (*Process, error) {
 	for i, _ := range m.queues {
 		queue := m.queues[i]
 		if queue != nil && queue.Len() != 0 {
+			m.last = queue
 			return queue.(Scheduler).Get()
 		}
 	}
 	return nil, errors.New("Nothing to return")
 }
 
+func (m *MultilevelQueue) Reason() string {
+	if m.last == nil {
+		return ""
+	}
+	reason := fmt.Sprintf("%s queue, the first with processes", m.last.Name())
+	if r, ok := m.last.(Reasoner); ok {
+		reason += "; " + r.Reason()
+	}
+	return reason
+}
+
 func (m *MultilevelQueue) Read() (*Process, error) {
 	for i, _ := range m.queues {
 		queue := m.queues[i]
@@ -57,6 +71,15 @@ func (m *MultilevelQueue) Add(p *Process) error {
 	}
 }
 
+func (m *MultilevelQueue) Remove(p *Process) bool {
+	for _, queue := range m.queues {
+		if r, ok := queue.(Remover); ok && r.Remove(p) {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *MultilevelQueue) Len() int {
 	length := 0
 	for i, _ := range m.queues {
@@ -68,6 +91,16 @@ func (m *MultilevelQueue) Len() int {
 	return length
 }
 
+func (m *MultilevelQueue) Processes() Processes {
+	processes := Processes{}
+	for i := range m.queues {
+		if m.queues[i] != nil {
+			processes = append(processes, m.queues[i].Processes()...)
+		}
+	}
+	return processes
+}
+
 func (m *MultilevelQueue) String() []string {
 	//	str := "\n\n\t\t--------------------- " + m.name + " ---------------------\n"
 	//	for i, _ := range m.queues {
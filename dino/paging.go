@@ -0,0 +1,144 @@
+package dino
+
+import "fmt"
+
+// ReplacementPolicy decides which resident page is evicted when a process with a full resident set faults
+type ReplacementPolicy interface {
+	Name() string
+	// Referenced is called when resident[i] is referenced again, and returns the resident pages reordered as needed
+	Referenced(resident []int, i int) []int
+	// Victim returns the index of the page to evict. future holds the upcoming references, if they are known
+	Victim(resident []int, future []int) int
+}
+
+// FIFOPolicy evicts the page that has been resident the longest
+type FIFOPolicy struct{}
+
+func (FIFOPolicy) Name() string {
+	return "FIFO"
+}
+
+func (FIFOPolicy) Referenced(resident []int, i int) []int {
+	return resident
+}
+
+func (FIFOPolicy) Victim(resident []int, future []int) int {
+	return 0
+}
+
+// LRUPolicy evicts the least recently used page
+type LRUPolicy struct{}
+
+func (LRUPolicy) Name() string {
+	return "LRU"
+}
+
+// Referenced moves the page to the end, so resident pages stay ordered from least to most recently used
+func (LRUPolicy) Referenced(resident []int, i int) []int {
+	page := resident[i]
+	return append(append(resident[:i:i], resident[i+1:]...), page)
+}
+
+func (LRUPolicy) Victim(resident []int, future []int) int {
+	return 0
+}
+
+func replacementPolicyByName(name string) (ReplacementPolicy, error) {
+	for _, policy := range []ReplacementPolicy{FIFOPolicy{}, LRUPolicy{}} {
+		if policy.Name() == name {
+			return policy, nil
+		}
+	}
+	return nil, fmt.Errorf("Unknown replacement policy '%s'", name)
+}
+
+// checkReplacementPolicy fails with the error replacementPolicyByName gives on Load when policy isn't a built-in one
+func checkReplacementPolicy(policy ReplacementPolicy) error {
+	if known, err := replacementPolicyByName(policy.Name()); err != nil {
+		return err
+	} else if known != policy {
+		return fmt.Errorf("Unknown replacement policy '%s'", policy.Name())
+	}
+	return nil
+}
+
+// PagedMemory tracks which logical pages of each process are resident, counting a page fault
+// every time a process references a page that isn't
+type PagedMemory struct {
+	ResidentSetSize int               // maximum resident pages per process, 0 means unlimited
+	Policy          ReplacementPolicy // page replacement policy, FIFO by default
+	resident        map[string][]int  // process ID -> resident pages, in the order kept by Policy
+	faults          map[string]int    // process ID -> page faults
+}
+
+func NewPagedMemory(residentSetSize int) *PagedMemory {
+	return &PagedMemory{
+		ResidentSetSize: residentSetSize,
+		Policy:          FIFOPolicy{},
+		resident:        map[string][]int{},
+		faults:          map[string]int{},
+	}
+}
+
+// Access references a page of p, loading it (and evicting a page chosen by the Policy if the resident set is full)
+// when it isn't resident. Returns whether the access caused a page fault
+func (pm *PagedMemory) Access(p *Process, page int) (fault bool) {
+	return pm.access(p, page, nil)
+}
+
+func (pm *PagedMemory) access(p *Process, page int, future []int) (fault bool) {
+	pages := pm.resident[p.ID]
+	for i, resident := range pages {
+		if resident == page {
+			pm.resident[p.ID] = pm.Policy.Referenced(pages, i)
+			return false
+		}
+	}
+
+	pm.faults[p.ID]++
+	if pm.ResidentSetSize > 0 && len(pages) >= pm.ResidentSetSize {
+		victim := pm.Policy.Victim(pages, future)
+		pages = append(pages[:victim:victim], pages[victim+1:]...)
+	}
+	pm.resident[p.ID] = append(pages, page)
+	return true
+}
+
+// Release drops the resident set of p, keeping its page fault count
+func (pm *PagedMemory) Release(p *Process) {
+	delete(pm.resident, p.ID)
+}
+
+// Resident returns the pages of p currently in memory
+func (pm *PagedMemory) Resident(p *Process) []int {
+	return append([]int{}, pm.resident[p.ID]...)
+}
+
+func (pm *PagedMemory) PageFaults(p *Process) int {
+	return pm.faults[p.ID]
+}
+
+// touchPage references the page p accesses at its current program counter, if it has an access sequence
+func (pm *PagedMemory) touchPage(p *Process) {
+	if len(p.AccessSequence) == 0 {
+		return
+	}
+	next := p.ProgramCounter % len(p.AccessSequence)
+	pm.access(p, p.AccessSequence[next], p.AccessSequence[next+1:])
+}
+
+// PageFaultCurve replays a reference string with every resident set size from minFrames to maxFrames, returning the
+// page faults for each of them. Under FIFO the curve may go up as frames are added (Belady's anomaly)
+func PageFaultCurve(refs []int, minFrames, maxFrames int, policy ReplacementPolicy) []int {
+	curve := []int{}
+	p := &Process{ID: "reference string"}
+	for frames := minFrames; frames <= maxFrames; frames++ {
+		pm := NewPagedMemory(frames)
+		pm.Policy = policy
+		for i, page := range refs {
+			pm.access(p, page, refs[i+1:])
+		}
+		curve = append(curve, pm.PageFaults(p))
+	}
+	return curve
+}
@@ -0,0 +1,72 @@
+package dino
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPageFaults(t *testing.T) {
+	p := testProcess()
+	pm := NewPagedMemory(3)
+
+	// 0F 1F 2F 0 3F(-0) 0F(-1) 4F(-2) 2F(-3) 0 3F(-0)
+	for _, page := range []int{0, 1, 2, 0, 3, 0, 4, 2, 0, 3} {
+		pm.Access(p, page)
+	}
+	assert.Equal(t, 8, pm.PageFaults(p))
+	assert.Equal(t, []int{4, 2, 3}, pm.Resident(p))
+
+	unlimited := NewPagedMemory(0)
+	for _, page := range []int{0, 1, 2, 0, 3, 0, 4, 2, 0, 3} {
+		unlimited.Access(p, page)
+	}
+	assert.Equal(t, 5, unlimited.PageFaults(p), "Without a resident set limit only the first reference of each page faults")
+}
+
+func TestDinoPaging(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableIO = true
+	d.Paging = NewPagedMemory(2)
+
+	p := testProcess()
+	p.AccessSequence = []int{0, 1, 0, 2}
+	for i := 0; i < 4; i++ {
+		d.execute(p)
+	}
+	// 0F 1F 0 2F(-0)
+	assert.Equal(t, 3, d.Paging.PageFaults(p))
+}
+
+func TestLRUPolicy(t *testing.T) {
+	// Reference string from Silberschatz's Operating System Concepts, with 3 frames
+	refs := []int{7, 0, 1, 2, 0, 3, 0, 4, 2, 3, 0, 3, 2, 1, 2, 0, 1, 7, 0, 1}
+	p := testProcess()
+
+	lru := NewPagedMemory(3)
+	lru.Policy = LRUPolicy{}
+	for _, page := range refs {
+		lru.Access(p, page)
+	}
+	assert.Equal(t, 12, lru.PageFaults(p))
+	assert.Equal(t, []int{7, 0, 1}, lru.Resident(p), "Resident pages should be ordered from least to most recently used")
+
+	fifo := NewPagedMemory(3)
+	for _, page := range refs {
+		fifo.Access(p, page)
+	}
+	assert.Equal(t, 15, fifo.PageFaults(p))
+}
+
+func TestPageFaultCurve(t *testing.T) {
+	refs := []int{1, 2, 3, 4, 1, 2, 5, 1, 2, 3, 4, 5}
+
+	fifo := PageFaultCurve(refs, 1, 5, FIFOPolicy{})
+	assert.Equal(t, []int{12, 12, 9, 10, 5}, fifo)
+	assert.True(t, fifo[3] > fifo[2], "FIFO should fault more with 4 frames than with 3")
+
+	lru := PageFaultCurve(refs, 1, 5, LRUPolicy{})
+	for i := 1; i < len(lru); i++ {
+		assert.True(t, lru[i] <= lru[i-1], "LRU faults should never increase with more frames: %v", lru)
+	}
+}
@@ -0,0 +1,87 @@
+package dino
+
+import "fmt"
+
+// FixedPartitionMemory splits memory into partitions of fixed sizes, each holding at most one process. A process
+// takes the whole partition it's placed in, so the cells it doesn't use are wasted (internal fragmentation). Memory
+// must only be changed through it
+type FixedPartitionMemory struct {
+	Memory     Memory
+	partitions []MemoryBlock
+	owners     []*Process // process in each partition, nil if it's free
+	lastWaste  int
+}
+
+// NewFixedPartitionMemory splits m, from its start, into partitions of the given sizes
+func NewFixedPartitionMemory(m Memory, sizes ...int) (*FixedPartitionMemory, error) {
+	f := &FixedPartitionMemory{Memory: m, owners: make([]*Process, len(sizes))}
+	start := 0
+	for _, size := range sizes {
+		if size < 1 {
+			return nil, fmt.Errorf("Cannot partition -- partition sizes should be positive, got %d", size)
+		}
+		f.partitions = append(f.partitions, MemoryBlock{Start: start, Size: size, Name: FREE_BLOCK})
+		start += size
+	}
+	if start > len(m) {
+		return nil, fmt.Errorf("Cannot partition -- partitions take %d cells but memory has %d", start, len(m))
+	}
+	return f, nil
+}
+
+// Partitions returns the partitions, ordered by address
+func (f *FixedPartitionMemory) Partitions() []MemoryBlock {
+	return append([]MemoryBlock{}, f.partitions...)
+}
+
+// Allocate places p at the start of the smallest free partition it fits in (the lowest-addressed among equals)
+func (f *FixedPartitionMemory) Allocate(p *Process) error {
+	if p == nil {
+		return ErrNilProcess
+	}
+	best := -1
+	for i, partition := range f.partitions {
+		if f.owners[i] == nil && partition.Size >= p.SizeInKB && (best == -1 || partition.Size < f.partitions[best].Size) {
+			best = i
+		}
+	}
+	if best == -1 {
+		return ErrNoContiguousSpace
+	}
+	if err := f.Memory.Allocate(p, f.partitions[best].Start); err != nil {
+		return err
+	}
+	f.owners[best] = p
+	f.lastWaste = f.partitions[best].Size - p.SizeInKB
+	return nil
+}
+
+// LastAllocationWaste returns the cells of its partition the last allocated process doesn't use
+func (f *FixedPartitionMemory) LastAllocationWaste() int {
+	return f.lastWaste
+}
+
+// InternalFragmentation returns the cells wasted by every allocated process
+func (f *FixedPartitionMemory) InternalFragmentation() int {
+	waste := 0
+	for i, p := range f.owners {
+		if p != nil {
+			waste += f.partitions[i].Size - p.SizeInKB
+		}
+	}
+	return waste
+}
+
+// ReleaseProcess frees the partition of p
+func (f *FixedPartitionMemory) ReleaseProcess(p *Process) (bool, error) {
+	for i, owner := range f.owners {
+		if owner == p && p != nil {
+			released, err := f.Memory.ReleaseProcess(p)
+			if err == nil {
+				f.owners[i] = nil
+			}
+			return released, err
+		}
+	}
+	return false, fmt.Errorf("Cannot release -- process is not in any partition")
+}
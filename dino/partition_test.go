@@ -0,0 +1,42 @@
+package dino
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixedPartitionMemory(t *testing.T) {
+	f, err := NewFixedPartitionMemory(make(Memory, 40), 8, 16, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 24, f.Partitions()[2].Start)
+
+	// 7 KB go to the 8 KB partition, the smallest that fits
+	small := &Process{ID: "small", Name: "small", SizeInKB: 7}
+	assert.NoError(t, f.Allocate(small))
+	assert.Equal(t, 0, small.MemoryAddress)
+	assert.Equal(t, 1, f.LastAllocationWaste())
+
+	// 13 KB go to the oversized 16 KB partition, as the 10 KB one is too small
+	big := &Process{ID: "big", Name: "big", SizeInKB: 13}
+	assert.NoError(t, f.Allocate(big))
+	assert.Equal(t, 8, big.MemoryAddress)
+	assert.Equal(t, 3, f.LastAllocationWaste())
+	assert.Equal(t, 4, f.InternalFragmentation())
+
+	assert.True(t, errors.Is(f.Allocate(&Process{ID: "other", SizeInKB: 12}), ErrNoContiguousSpace), "Free cells of other partitions can't be used")
+	assert.Equal(t, 3, f.LastAllocationWaste(), "Failed allocations don't change it")
+
+	released, err := f.ReleaseProcess(big)
+	assert.NoError(t, err)
+	assert.True(t, released)
+	assert.Equal(t, 1, f.InternalFragmentation())
+	_, err = f.ReleaseProcess(big)
+	assert.Error(t, err)
+
+	_, err = NewFixedPartitionMemory(make(Memory, 10), 8, 8)
+	assert.Error(t, err)
+	_, err = NewFixedPartitionMemory(make(Memory, 10), 0)
+	assert.Error(t, err)
+}
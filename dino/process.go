@@ -1,9 +1,11 @@
 package dino
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"time"
-
-	"github.com/nu7hatch/gouuid"
 )
 
 const (
@@ -11,13 +13,33 @@ const (
 	PT_INTERACTIVE    = ProcessType("Interactive")
 	PT_NONINTERACTIVE = ProcessType("Noninteractive")
 
+	// Process Classes
+	PC_CPU_BOUND = ProcessClass("CPU-bound")
+	PC_IO_BOUND  = ProcessClass("IO-bound")
+	PC_UNKNOWN   = ProcessClass("Unknown")
+
 	// Burst Types
 	BT_CPU = iota
 	BT_IO
 )
 
+const (
+	// Touch Patterns, the zero value doesn't touch memory
+	TP_NONE       = TouchPattern("")
+	TP_SEQUENTIAL = TouchPattern("Sequential")
+	TP_RANDOM     = TouchPattern("Random")
+)
+
+const (
+	// Nice values, like Unix: the higher, the less CPU the process gets
+	MIN_NICE = -20
+	MAX_NICE = 19
+)
+
 type Processes []*Process
+type TouchPattern string
 type ProcessType string
+type ProcessClass string
 
 type Bursts []BurstType
 type BurstType int
@@ -33,24 +55,249 @@ type Process struct {
 
 	IsAllocated   bool
 	MemoryAddress int
+
+	AccessSequence []int             // logical pages referenced as the process runs, one per executed burst
+	Weight         int               // share of the CPU under weighted fair scheduling, 0 counts as 1
+	Nice           int               // biases the share of the CPU, from MIN_NICE (most) to MAX_NICE (least)
+	Resizes        []Resize          // pending changes of size, ordered by step
+	Tags           map[string]string // labels for reports, ignored by the simulator
+	TouchPattern   TouchPattern      // how the process touches the cells of its block each time it runs
+	Pinned         bool              // kernel processes that are never moved by compaction nor evicted
+	Parent         *Process          // process that spawned it, nil for the ones that arrived on their own
+	Fragments      []MemoryBlock     // blocks it was scattered across by AllocateScatter, nil when it's contiguous
+	MaxAddress     int               // highest address the process can reach, e.g. 65535 for 16-bit ones. 0 for any
+
+	ArrivalStep  int // step the process arrived at the new queue
+	FirstRunStep int // step the process was first dispatched, 0 while it hasn't
+	FinishStep   int // step the process terminated, 0 while it hasn't
+	WaitTime     int // steps spent in the ready queue without running
+
+	step            int  // step of the Dino it's in, kept up to date so Checkpoint knows when it's taken
+	relativeResizes bool // Resizes count from the step it's injected at, as restored from a checkpoint
+}
+
+// Resize asks for the process to have SizeInKB from the given step on
+type Resize struct {
+	Step     int
+	SizeInKB int
 }
 
 func (d *Dino) RandomProcess() *Process {
-	uuid, _ := uuid.NewV4()
-	uuidString := uuid.String()
-	processType := randomType()
+	uuidString := randomUUID(d.rand)
+	processType := randomType(d.rand)
 
 	return &Process{
 		ID:            uuidString,
 		Name:          abbrev(uuidString),
 		Type:          processType,
-		Bursts:        randomBursts(processType, 3, 10),
-		SizeInKB:      randomInteger(1, d.memorySize/5),
+		Bursts:        randomBursts(d.rand, processType, 3, 10),
+		SizeInKB:      randomInteger(d.rand, 1, d.memorySize/5),
 		IsAllocated:   false,
 		MemoryAddress: -1,
 	}
 }
 
+func (ps Processes) contains(p *Process) bool {
+	for i := range ps {
+		if ps[i] == p {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *Process) Lifespan() int {
 	return len(p.Bursts)
 }
+
+// Clone returns a copy of the process that doesn't share its bursts
+func (p *Process) Clone() *Process {
+	clone := *p
+	clone.Bursts = append(Bursts(nil), p.Bursts...)
+	clone.AccessSequence = append([]int(nil), p.AccessSequence...)
+	clone.Resizes = append([]Resize(nil), p.Resizes...)
+	clone.Fragments = append([]MemoryBlock(nil), p.Fragments...)
+	if p.Tags != nil {
+		clone.Tags = make(map[string]string, len(p.Tags))
+		for key, value := range p.Tags {
+			clone.Tags[key] = value
+		}
+	}
+	return &clone
+}
+
+// Equal reports whether both processes hold the same values in every field
+func (p *Process) Equal(other *Process) bool {
+	return len(p.Diff(other)) == 0
+}
+
+// Diff lists the fields that differ between both processes, as "Field: this -> other"
+func (p *Process) Diff(other *Process) []string {
+	if p == nil || other == nil {
+		if p != other {
+			return []string{fmt.Sprintf("Process: %v -> %v", p, other)}
+		}
+		return []string{}
+	}
+
+	diff := []string{}
+	field := func(name string, this, that interface{}) {
+		if this != that {
+			diff = append(diff, fmt.Sprintf("%s: %v -> %v", name, this, that))
+		}
+	}
+	field("ID", p.ID, other.ID)
+	field("Name", p.Name, other.Name)
+	field("Type", p.Type, other.Type)
+	field("ProgramCounter", p.ProgramCounter, other.ProgramCounter)
+	field("Bursts", fmt.Sprint(p.Bursts), fmt.Sprint(other.Bursts))
+	field("IOBurst", p.IOBurst, other.IOBurst)
+	field("SizeInKB", p.SizeInKB, other.SizeInKB)
+	field("IsAllocated", p.IsAllocated, other.IsAllocated)
+	field("MemoryAddress", p.MemoryAddress, other.MemoryAddress)
+	field("AccessSequence", fmt.Sprint(p.AccessSequence), fmt.Sprint(other.AccessSequence))
+	field("Weight", p.Weight, other.Weight)
+	field("Nice", p.Nice, other.Nice)
+	field("Resizes", fmt.Sprint(p.Resizes), fmt.Sprint(other.Resizes))
+	field("Tags", fmt.Sprint(p.Tags), fmt.Sprint(other.Tags))
+	field("TouchPattern", p.TouchPattern, other.TouchPattern)
+	field("Pinned", p.Pinned, other.Pinned)
+	field("Parent", p.ParentID(), other.ParentID())
+	field("Fragments", fmt.Sprint(p.Fragments), fmt.Sprint(other.Fragments))
+	field("MaxAddress", p.MaxAddress, other.MaxAddress)
+	field("ArrivalStep", p.ArrivalStep, other.ArrivalStep)
+	field("FirstRunStep", p.FirstRunStep, other.FirstRunStep)
+	field("FinishStep", p.FinishStep, other.FinishStep)
+	field("WaitTime", p.WaitTime, other.WaitTime)
+	return diff
+}
+
+// ParentID returns the ID of the parent of p, or "" if it has none
+func (p *Process) ParentID() string {
+	if p.Parent == nil {
+		return ""
+	}
+	return p.Parent.ID
+}
+
+// processJSON is the JSON form of a Process, which refers to its parent by ID so that it can't hold cycles
+type processJSON struct {
+	ID             string
+	Name           string
+	Type           ProcessType
+	SizeInKB       int
+	IsAllocated    bool
+	MemoryAddress  int
+	Nice           int
+	Weight         int
+	ProgramCounter int
+	Bursts         Bursts
+	ParentID       string `json:",omitempty"`
+}
+
+// MarshalJSON encodes the identity, placement, priority and bursts of p, along with the ID of its parent
+func (p *Process) MarshalJSON() ([]byte, error) {
+	return json.Marshal(processJSON{
+		ID:             p.ID,
+		Name:           p.Name,
+		Type:           p.Type,
+		SizeInKB:       p.SizeInKB,
+		IsAllocated:    p.IsAllocated,
+		MemoryAddress:  p.MemoryAddress,
+		Nice:           p.Nice,
+		Weight:         p.Weight,
+		ProgramCounter: p.ProgramCounter,
+		Bursts:         p.Bursts,
+		ParentID:       p.ParentID(),
+	})
+}
+
+// RemainingBursts returns how many bursts the process still has to run
+func (p *Process) RemainingBursts() int {
+	return p.Lifespan() - p.ProgramCounter
+}
+
+// BurstRules are the constraints checked by ValidateBurstsWith, on top of having bursts of known types
+type BurstRules struct {
+	NoConsecutiveIO bool // every IO burst should be followed by a CPU burst
+}
+
+// ValidateBursts checks that the process has at least one burst, and that every burst is either BT_CPU or BT_IO
+func (p *Process) ValidateBursts() error {
+	return p.ValidateBurstsWith(BurstRules{})
+}
+
+// ValidateBurstsWith checks the bursts like ValidateBursts, along with the given rules
+func (p *Process) ValidateBurstsWith(rules BurstRules) error {
+	if len(p.Bursts) == 0 {
+		return errors.New("Invalid bursts -- the process has no bursts")
+	}
+	for i, burst := range p.Bursts {
+		if burst != BT_CPU && burst != BT_IO {
+			return fmt.Errorf("Invalid bursts -- burst %d has unknown type %d", i, burst)
+		} else if rules.NoConsecutiveIO && i > 0 && burst == BT_IO && p.Bursts[i-1] == BT_IO {
+			return fmt.Errorf("Invalid bursts -- bursts %d and %d are both IO", i-1, i)
+		}
+	}
+	return nil
+}
+
+// Turnaround returns how many steps passed from the arrival of the process to its termination, both included, or 0
+// if it hasn't terminated
+func (p *Process) Turnaround() int {
+	if p.FinishStep == 0 {
+		return 0
+	}
+	return p.FinishStep - p.ArrivalStep + 1
+}
+
+// ResponseTime returns how many steps passed from the arrival of the process until it was first dispatched, or 0 if
+// it hasn't been
+func (p *Process) ResponseTime() int {
+	if p.FirstRunStep == 0 {
+		return 0
+	}
+	return p.FirstRunStep - p.ArrivalStep
+}
+
+// EffectiveWeight returns the weight used for scheduling, which is at least 1
+func (p *Process) EffectiveWeight() int {
+	if p.Weight < 1 {
+		return 1
+	}
+	return p.Weight
+}
+
+// EffectiveNice returns Nice clamped to the valid range
+func (p *Process) EffectiveNice() int {
+	if p.Nice < MIN_NICE {
+		return MIN_NICE
+	} else if p.Nice > MAX_NICE {
+		return MAX_NICE
+	}
+	return p.Nice
+}
+
+// ShareWeight returns the EffectiveWeight scaled by the nice value as Unix does, so each nice level gets about 25% less
+// CPU than the one below it
+func (p *Process) ShareWeight() float64 {
+	return float64(p.EffectiveWeight()) / math.Pow(1.25, float64(p.EffectiveNice()))
+}
+
+// Classify tells whether the process spends most of its bursts on the CPU or on IO. Ties count as IO-bound
+func (p *Process) Classify() ProcessClass {
+	cpu, io := 0, 0
+	for _, burst := range p.Bursts {
+		if burst == BT_CPU {
+			cpu++
+		} else if burst == BT_IO {
+			io++
+		}
+	}
+	if cpu+io == 0 {
+		return PC_UNKNOWN
+	} else if cpu > io {
+		return PC_CPU_BOUND
+	}
+	return PC_IO_BOUND
+}
@@ -0,0 +1,103 @@
+package dino
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// ProcessHeap is a priority queue of processes ordered by a less function, where processes that are neither less
+// than the other come out in the order they were pushed. Pushes and pops take O(log n)
+type ProcessHeap struct {
+	h processHeap
+}
+
+func NewProcessHeap(less func(a, b *Process) bool) *ProcessHeap {
+	return &ProcessHeap{h: processHeap{less: less}}
+}
+
+func (ph *ProcessHeap) Push(p *Process) {
+	heap.Push(&ph.h, heapEntry{process: p, order: ph.h.pushed})
+	ph.h.pushed++
+}
+
+// Pop removes and returns the first process, or nil if the heap is empty
+func (ph *ProcessHeap) Pop() *Process {
+	if ph.h.Len() == 0 {
+		return nil
+	}
+	return heap.Pop(&ph.h).(heapEntry).process
+}
+
+// Peek returns the first process without removing it, or nil if the heap is empty
+func (ph *ProcessHeap) Peek() *Process {
+	if ph.h.Len() == 0 {
+		return nil
+	}
+	return ph.h.entries[0].process
+}
+
+// Remove takes p out of the heap, reporting whether it was there
+func (ph *ProcessHeap) Remove(p *Process) bool {
+	for i, entry := range ph.h.entries {
+		if entry.process == p {
+			heap.Remove(&ph.h, i)
+			return true
+		}
+	}
+	return false
+}
+
+func (ph *ProcessHeap) Len() int {
+	return ph.h.Len()
+}
+
+// Processes returns the processes in the heap, in the order they were pushed
+func (ph *ProcessHeap) Processes() Processes {
+	entries := append([]heapEntry{}, ph.h.entries...)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].order < entries[j].order })
+	ordered := Processes{}
+	for _, entry := range entries {
+		ordered = append(ordered, entry.process)
+	}
+	return ordered
+}
+
+type heapEntry struct {
+	process *Process
+	order   int // position in which it was pushed
+}
+
+// processHeap implements heap.Interface
+type processHeap struct {
+	entries []heapEntry
+	less    func(a, b *Process) bool
+	pushed  int
+}
+
+func (h processHeap) Len() int {
+	return len(h.entries)
+}
+
+func (h processHeap) Less(i, j int) bool {
+	a, b := h.entries[i], h.entries[j]
+	if h.less(a.process, b.process) {
+		return true
+	} else if h.less(b.process, a.process) {
+		return false
+	}
+	return a.order < b.order
+}
+
+func (h processHeap) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+}
+
+func (h *processHeap) Push(x interface{}) {
+	h.entries = append(h.entries, x.(heapEntry))
+}
+
+func (h *processHeap) Pop() interface{} {
+	last := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+	return last
+}
@@ -0,0 +1,40 @@
+package dino
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessHeap(t *testing.T) {
+	sizes := []int{5, 1, 4, 1, 3, 9}
+	processes := Processes{}
+	for i, size := range sizes {
+		processes = append(processes, &Process{ID: string(rune('a' + i)), SizeInKB: size})
+	}
+	pop := func(h *ProcessHeap) []string {
+		ids := []string{}
+		for p := h.Pop(); p != nil; p = h.Pop() {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	smallest := NewProcessHeap(func(a, b *Process) bool { return a.SizeInKB < b.SizeInKB })
+	largest := NewProcessHeap(func(a, b *Process) bool { return a.SizeInKB > b.SizeInKB })
+	fifo := NewProcessHeap(func(a, b *Process) bool { return false })
+	for _, p := range processes {
+		smallest.Push(p)
+		largest.Push(p)
+		fifo.Push(p)
+	}
+	assert.Equal(t, 6, smallest.Len())
+	assert.Equal(t, processes[1], smallest.Peek())
+	assert.Equal(t, processes, smallest.Processes())
+
+	assert.Equal(t, []string{"b", "d", "e", "c", "a", "f"}, pop(smallest), "Equal processes keep the order they were pushed")
+	assert.Equal(t, []string{"f", "a", "c", "e", "b", "d"}, pop(largest))
+	assert.Equal(t, []string{"a", "b", "c", "d", "e", "f"}, pop(fifo))
+	assert.Nil(t, fifo.Peek())
+	assert.Equal(t, 0, fifo.Len())
+}
@@ -0,0 +1,177 @@
+package dino
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessEqual(t *testing.T) {
+	p := testProcess()
+	clone := p.Clone()
+	assert.True(t, p.Equal(clone))
+	assert.Empty(t, p.Diff(clone))
+
+	clone.Bursts[0] = BT_IO
+	assert.False(t, p.Equal(clone), "Clones should not share their bursts")
+	assert.False(t, p.Equal(nil))
+	assert.True(t, (*Process)(nil).Equal(nil))
+}
+
+func TestProcessDiff(t *testing.T) {
+	p := testProcess()
+	other := p.Clone()
+	other.ProgramCounter = 3
+	other.SizeInKB = 20
+	other.IsAllocated = true
+
+	diff := p.Diff(other)
+	assert.Equal(t, []string{"ProgramCounter: 0 -> 3", "SizeInKB: 10 -> 20", "IsAllocated: false -> true"}, diff)
+}
+
+func TestProcessMarshalJSON(t *testing.T) {
+	parent := &Process{ID: "parent", Name: "prnt", SizeInKB: 10, MemoryAddress: -1}
+	child := &Process{ID: "child", Name: "chld", SizeInKB: 5, Nice: 3, Bursts: Bursts{BT_CPU, BT_IO}, MemoryAddress: 20, IsAllocated: true, Parent: parent}
+	parent.Parent = child // a cycle a plain struct encoding would never finish
+
+	encoded, err := json.Marshal(child)
+	assert.NoError(t, err)
+	decoded := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, "parent", decoded["ParentID"])
+	assert.Equal(t, "chld", decoded["Name"])
+	assert.Equal(t, 20.0, decoded["MemoryAddress"])
+	assert.Equal(t, 3.0, decoded["Nice"])
+	assert.Equal(t, []interface{}{float64(BT_CPU), float64(BT_IO)}, decoded["Bursts"])
+	assert.NotContains(t, decoded, "Parent")
+
+	encoded, err = json.Marshal(&Process{ID: "orphan"})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(encoded), "ParentID")
+
+	// Snapshots keep the parent, even when it's no longer in the simulator
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	parent.Parent = nil
+	child.IsAllocated, child.MemoryAddress, child.Type = false, -1, PT_INTERACTIVE
+	assert.NoError(t, d.Inject(child))
+	clone := cloneDino(t, d)
+	cloned, _, err := clone.ProcessByID("child")
+	assert.NoError(t, err)
+	assert.True(t, cloned.Equal(child))
+	assert.True(t, cloned.Parent.Equal(parent))
+}
+
+func TestClassify(t *testing.T) {
+	cpuHeavy := &Process{Bursts: Bursts{BT_CPU, BT_CPU, BT_IO, BT_CPU}}
+	ioHeavy := &Process{Bursts: Bursts{BT_IO, BT_CPU, BT_IO, BT_IO}}
+	balanced := &Process{Bursts: Bursts{BT_IO, BT_CPU}}
+
+	assert.Equal(t, PC_CPU_BOUND, cpuHeavy.Classify())
+	assert.Equal(t, PC_IO_BOUND, ioHeavy.Classify())
+	assert.Equal(t, PC_IO_BOUND, balanced.Classify())
+	assert.Equal(t, PC_UNKNOWN, (&Process{}).Classify())
+}
+
+func TestValidateBursts(t *testing.T) {
+	valid := &Process{Bursts: Bursts{BT_CPU, BT_IO, BT_CPU, BT_IO}}
+	assert.NoError(t, valid.ValidateBursts())
+	assert.NoError(t, valid.ValidateBurstsWith(BurstRules{NoConsecutiveIO: true}))
+
+	consecutiveIO := &Process{Bursts: Bursts{BT_CPU, BT_IO, BT_IO, BT_CPU}}
+	assert.NoError(t, consecutiveIO.ValidateBursts())
+	assert.Error(t, consecutiveIO.ValidateBurstsWith(BurstRules{NoConsecutiveIO: true}))
+
+	assert.Error(t, (&Process{}).ValidateBursts())
+	assert.Error(t, (&Process{Bursts: Bursts{}}).ValidateBursts())
+	assert.Error(t, (&Process{Bursts: Bursts{BT_CPU, BurstType(7)}}).ValidateBursts())
+
+	d := NewSeeded(100, 1)
+	d.BurstRules.NoConsecutiveIO = true
+	assert.Error(t, d.Inject(&Process{ID: "empty", Type: PT_INTERACTIVE, SizeInKB: 10, MemoryAddress: -1}))
+	assert.Error(t, d.Inject(&Process{ID: "io", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: consecutiveIO.Bursts, MemoryAddress: -1}))
+	assert.EqualError(t, d.Inject(&Process{ID: "untyped", SizeInKB: 10, Bursts: valid.Bursts, MemoryAddress: -1}), "Cannot inject -- unknown process type ''")
+	assert.Error(t, d.Inject(&Process{ID: "batch", Type: ProcessType("Batch"), SizeInKB: 10, Bursts: valid.Bursts, MemoryAddress: -1}))
+	assert.EqualError(t, d.Inject(&Process{ID: "weightless", Type: PT_INTERACTIVE, Bursts: valid.Bursts, MemoryAddress: -1}), "Cannot inject -- invalid size 0")
+	assert.NoError(t, d.Inject(&Process{ID: "valid", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: valid.Bursts, MemoryAddress: -1}))
+	assert.Equal(t, 1, d.newQueue.Len())
+	_, err := d.Step()
+	assert.NoError(t, err)
+	err = d.Inject(&Process{ID: "valid", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: valid.Bursts, MemoryAddress: -1})
+	assert.EqualError(t, err, "Cannot inject -- ID 'valid' is already taken")
+}
+
+func TestProcessCheckpoint(t *testing.T) {
+	long := Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU}
+	p := &Process{ID: "p", Name: "p", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: long, Nice: 2, Tags: map[string]string{"app": "db"}, MemoryAddress: -1}
+	here := NewSeeded(100, 1)
+	here.DisableArrivals = true
+	here.Inject(p)
+	here.Step()
+
+	// The checkpoint survives a round trip through JSON, as if sent to another machine
+	encoded, err := json.Marshal(p.Checkpoint())
+	assert.NoError(t, err)
+	var checkpoint ProcessCheckpoint
+	assert.NoError(t, json.Unmarshal(encoded, &checkpoint))
+	there := NewSeeded(50, 2)
+	there.DisableArrivals = true
+	assert.Equal(t, 1, checkpoint.Step)
+	migrated := ProcessFromCheckpoint(checkpoint)
+	assert.Equal(t, p.ProgramCounter, migrated.ProgramCounter)
+	assert.Equal(t, -1, migrated.MemoryAddress)
+	assert.False(t, migrated.IsAllocated)
+
+	assert.NoError(t, there.Inject(migrated))
+	for !here.IsComplete() || !there.IsComplete() {
+		here.Step()
+		there.Step()
+		assert.Equal(t, p.ProgramCounter, migrated.ProgramCounter)
+		assert.Equal(t, p.Bursts, migrated.Bursts)
+	}
+	assert.Equal(t, p.FinishStep, migrated.FinishStep+1, "It had run once before migrating")
+	assert.Equal(t, p.Tags, migrated.Tags)
+	assert.Equal(t, p.Nice, migrated.Nice)
+}
+
+func TestProcessCheckpointResizes(t *testing.T) {
+	here := NewSeeded(100, 1)
+	here.DisableArrivals = true
+	long := Bursts{}
+	for i := 0; i < 40; i++ {
+		long = append(long, BT_CPU)
+	}
+	p := &Process{ID: "p", Name: "p", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: long, MemoryAddress: -1}
+	p.Resizes = []Resize{{Step: 8, SizeInKB: 20}}
+	here.Inject(p)
+	for i := 0; i < 5; i++ {
+		here.Step()
+	}
+
+	checkpoint := p.Checkpoint()
+	assert.Equal(t, 5, checkpoint.Step)
+	assert.Equal(t, []Resize{{Step: 8, SizeInKB: 20}}, checkpoint.Resizes)
+	clone := cloneDino(t, here)
+	cloned, _, _ := clone.ProcessByID("p")
+	assert.Equal(t, checkpoint, cloned.Checkpoint(), "A loaded Dino knows its step too")
+
+	// Migrated to a Dino that ran longer, the resize is still as far in the future
+	there := NewSeeded(100, 2)
+	there.DisableArrivals = true
+	for i := 0; i < 20; i++ {
+		there.Step()
+	}
+	migrated := ProcessFromCheckpoint(checkpoint)
+	assert.NoError(t, there.Inject(migrated))
+	assert.Equal(t, []Resize{{Step: 23, SizeInKB: 20}}, migrated.Resizes)
+	assert.Equal(t, 8, p.Resizes[0].Step, "The process keeps its own steps")
+	for there.StepCount() < 22 {
+		there.Step()
+	}
+	assert.Equal(t, 10, migrated.SizeInKB, "Not resized before its step")
+	for there.StepCount() < 24 {
+		there.Step()
+	}
+	assert.Equal(t, 20, migrated.SizeInKB)
+}
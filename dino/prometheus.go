@@ -0,0 +1,24 @@
+package dino
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ExportPrometheus returns a handler serving the metrics of the Dino in the Prometheus text format. The Dino isn't
+// safe for concurrent use, so scrapes should be serialized with the steps, e.g. by stepping from the same goroutine
+func (d *Dino) ExportPrometheus() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		metric := func(name, kind, help string, value interface{}) {
+			fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, kind, name, value)
+		}
+		metric("dino_free_memory_kb", "gauge", "Free memory in KB.", d.Memory.TotalFree())
+		metric("dino_fragmentation_ratio", "gauge", "Fraction of free memory outside the largest hole.", d.Memory.FragmentationRatio())
+		metric("dino_ready_queue_length", "gauge", "Processes waiting in the ready queue.", d.readyQueue.Len()+len(d.boosted))
+		metric("dino_allocations_total", "counter", "Processes admitted into memory.", d.metrics.Allocations)
+		metric("dino_allocation_failures_total", "counter", "Admission attempts that found no space.", d.metrics.AllocationAttempts-d.metrics.Allocations)
+		metric("dino_processes_terminated_total", "counter", "Processes that finished or were killed.", len(d.terminated))
+		metric("dino_steps_total", "counter", "Steps simulated.", d.step)
+	})
+}
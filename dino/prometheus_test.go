@@ -0,0 +1,33 @@
+package dino
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportPrometheus(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	d.Inject(&Process{ID: "a", Name: "a", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1})
+	d.Inject(&Process{ID: "huge", Name: "huge", Type: PT_INTERACTIVE, SizeInKB: 200, Bursts: Bursts{BT_CPU}, MemoryAddress: -1})
+	d.Step()
+
+	server := httptest.NewServer(d.ExportPrometheus())
+	defer server.Close()
+	response, err := server.Client().Get(server.URL)
+	assert.NoError(t, err)
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	assert.NoError(t, err)
+
+	assert.Contains(t, response.Header.Get("Content-Type"), "text/plain")
+	for _, name := range []string{"dino_free_memory_kb", "dino_fragmentation_ratio", "dino_ready_queue_length", "dino_allocations_total", "dino_allocation_failures_total", "dino_processes_terminated_total"} {
+		assert.Contains(t, string(body), "# TYPE "+name+" ")
+	}
+	assert.Contains(t, string(body), "\ndino_free_memory_kb 90\n")
+	assert.Contains(t, string(body), "\ndino_allocations_total 1\n")
+	assert.Contains(t, string(body), "\ndino_allocation_failures_total 1\n", "The huge process doesn't fit")
+}
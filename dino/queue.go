@@ -10,12 +10,17 @@ type Queue struct {
 	processes Processes
 }
 
+// NewQueue creates a first come, first served scheduler
+func NewQueue(name string) *Queue {
+	return &Queue{name: name}
+}
+
 func (q *Queue) Add(p *Process) error {
 	q.processes = append(q.processes, p)
 	return nil
 }
 func (q *Queue) Get() (*Process, error) {
-	if q != nil && q.processes != nil && q.processes[0] != nil {
+	if q != nil && len(q.processes) != 0 && q.processes[0] != nil {
 		copy := q.processes[0]
 		q.processes = q.processes[1:len(q.processes)]
 		return copy, nil
@@ -24,18 +29,33 @@ func (q *Queue) Get() (*Process, error) {
 	}
 }
 func (q *Queue) Read() (*Process, error) {
-	if q != nil && q.processes != nil && q.processes[0] != nil {
+	if q != nil && len(q.processes) != 0 && q.processes[0] != nil {
 		return q.processes[0], nil
 	} else {
 		return nil, errors.New("Dealing with nils")
 	}
 }
+func (q *Queue) Remove(p *Process) bool {
+	for i := range q.processes {
+		if q.processes[i] == p {
+			q.processes = append(q.processes[:i:i], q.processes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+func (q *Queue) Reason() string {
+	return "first come, first served"
+}
 func (q *Queue) Len() int {
 	return len(q.processes)
 }
 func (q *Queue) Name() string {
 	return q.name
 }
+func (q *Queue) Processes() Processes {
+	return append(Processes{}, q.processes...)
+}
 
 func (q *Queue) String() []string {
 	stringSlice := []string{}
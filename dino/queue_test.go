@@ -1,12 +1,15 @@
 package dino
 
 import (
+	"math/rand"
 	"testing"
 
 	"github.com/nu7hatch/gouuid"
 	"github.com/stretchr/testify/assert"
 )
 
+var testRand = rand.New(rand.NewSource(1))
+
 func testProcess() *Process {
 	uuid, _ := uuid.NewV4()
 	uuidString := uuid.String()
@@ -16,7 +19,7 @@ func testProcess() *Process {
 	return &Process{
 		ID:            uuidString,
 		Name:          abbrev(uuidString),
-		Type:          randomType(),
+		Type:          randomType(testRand),
 		SizeInKB:      10,
 		Bursts:        bursts,
 		IsAllocated:   false,
@@ -0,0 +1,59 @@
+package dino
+
+// RecentFirstAllocator places processes in the most recently freed hole they fit in, where memory is still hot, and
+// falls back to worst fit when none of the freed regions is free enough. Regions must be released through it to be
+// remembered
+type RecentFirstAllocator struct {
+	Memory Memory
+	freed  []MemoryBlock // regions released through the allocator, the most recent last
+}
+
+func NewRecentFirstAllocator(m Memory) *RecentFirstAllocator {
+	return &RecentFirstAllocator{Memory: m}
+}
+
+// Allocate places p at the start of the most recently freed region whose hole it fits in, or in the largest hole
+func (r *RecentFirstAllocator) Allocate(p *Process) error {
+	if p == nil {
+		return ErrNilProcess
+	}
+	r.forgetReused()
+	holes := r.Memory.Reachable(p).holes()
+	for i := len(r.freed) - 1; i >= 0; i-- {
+		region := r.freed[i]
+		for _, hole := range holes {
+			if hole.Start > region.Start || hole.Start+hole.Size <= region.Start {
+				continue
+			} else if hole.Start+hole.Size-region.Start >= p.SizeInKB {
+				return r.Memory.Allocate(p, region.Start)
+			} else if hole.Size >= p.SizeInKB {
+				return r.Memory.Allocate(p, hole.Start)
+			}
+		}
+	}
+	return r.Memory.AllocateWorstFit(p)
+}
+
+// ReleaseProcess releases p and remembers its region as the hottest one
+func (r *RecentFirstAllocator) ReleaseProcess(p *Process) (bool, error) {
+	if p == nil {
+		return false, ErrNilProcess
+	}
+	region := MemoryBlock{Start: p.MemoryAddress, Size: p.SizeInKB}
+	released, err := r.Memory.ReleaseProcess(p)
+	if released && err == nil {
+		r.freed = append(r.freed, region)
+	}
+	return released, err
+}
+
+// forgetReused drops the regions whose start has been allocated again
+func (r *RecentFirstAllocator) forgetReused() {
+	kept := r.freed[:0]
+	for _, region := range r.freed {
+		if r.Memory[region.Start] == nil {
+			kept = append(kept, region)
+		}
+	}
+	r.freed = kept
+}
@@ -0,0 +1,38 @@
+package dino
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecentFirstAllocator(t *testing.T) {
+	r := NewRecentFirstAllocator(make(Memory, 100))
+	a := &Process{ID: "a", SizeInKB: 10, MemoryAddress: -1}
+	b := &Process{ID: "b", SizeInKB: 20, MemoryAddress: -1}
+	c := &Process{ID: "c", SizeInKB: 10, MemoryAddress: -1}
+	assert.NoError(t, r.Memory.Allocate(a, 0))
+	assert.NoError(t, r.Memory.Allocate(b, 10))
+	assert.NoError(t, r.Memory.Allocate(c, 30))
+
+	// a leaves a hole of 10, b a hole of 20, and 60 are free at the end
+	_, err := r.ReleaseProcess(b)
+	assert.NoError(t, err)
+	_, err = r.ReleaseProcess(a)
+	assert.NoError(t, err)
+	d := &Process{ID: "d", SizeInKB: 8, MemoryAddress: -1}
+	assert.NoError(t, r.Allocate(d))
+	assert.Equal(t, 0, d.MemoryAddress, "a's region is the hottest")
+
+	e := &Process{ID: "e", SizeInKB: 15, MemoryAddress: -1}
+	assert.NoError(t, r.Allocate(e))
+	assert.Equal(t, 10, e.MemoryAddress, "It doesn't fit what's left of a's region, but it does b's")
+
+	f := &Process{ID: "f", SizeInKB: 30, MemoryAddress: -1}
+	assert.NoError(t, r.Allocate(f))
+	assert.Equal(t, 40, f.MemoryAddress, "Without a hot region it fits, it goes to the largest hole")
+	assert.Equal(t, ErrNilProcess, r.Allocate(nil))
+	released, err := r.ReleaseProcess(nil)
+	assert.False(t, released)
+	assert.Equal(t, ErrNilProcess, err)
+}
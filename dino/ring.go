@@ -0,0 +1,52 @@
+package dino
+
+// RingAllocator treats memory as a ring: each allocation goes to the first place where the process fits, searching
+// forward from where the previous allocation ended and wrapping around. Allocations are never split across the end
+// of memory, they must be contiguous
+type RingAllocator struct {
+	Memory Memory
+	cursor int
+}
+
+func NewRingAllocator(m Memory) *RingAllocator {
+	return &RingAllocator{Memory: m}
+}
+
+// Cursor returns the address where the next search starts
+func (r *RingAllocator) Cursor() int {
+	return r.cursor
+}
+
+// Allocate places p at the first fit after the cursor, wrapping to the start of memory if needed, and moves the
+// cursor right after it
+func (r *RingAllocator) Allocate(p *Process) error {
+	if p == nil {
+		return ErrNilProcess
+	}
+	start := -1
+	holes := r.Memory.Reachable(p).holes()
+	for _, hole := range holes { // from the cursor to the end of memory
+		from := hole.Start
+		if from < r.cursor {
+			from = r.cursor
+		}
+		if hole.Start+hole.Size-from >= p.SizeInKB {
+			start = from
+			break
+		}
+	}
+	for i := 0; start == -1 && i < len(holes) && holes[i].Start < r.cursor; i++ { // wrapped around
+		if holes[i].Size >= p.SizeInKB {
+			start = holes[i].Start
+		}
+	}
+	if start == -1 {
+		return ErrNoContiguousSpace
+	}
+
+	if err := r.Memory.Allocate(p, start); err != nil {
+		return err
+	}
+	r.cursor = (start + p.SizeInKB) % len(r.Memory)
+	return nil
+}
@@ -0,0 +1,47 @@
+package dino
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingAllocator(t *testing.T) {
+	r := NewRingAllocator(make(Memory, 20))
+	a := &Process{ID: "a", SizeInKB: 8}
+	b := &Process{ID: "b", SizeInKB: 8}
+	assert.NoError(t, r.Allocate(a))
+	assert.NoError(t, r.Allocate(b))
+	assert.Equal(t, 8, b.MemoryAddress)
+	assert.Equal(t, 16, r.Cursor())
+
+	// Only 4 cells are left before the end, and placements can't wrap
+	r.Memory.ReleaseProcess(a)
+	c := &Process{ID: "c", SizeInKB: 8}
+	assert.NoError(t, r.Allocate(c))
+	assert.Equal(t, 0, c.MemoryAddress, "Should wrap around instead of straddling the boundary")
+	assert.Equal(t, 8, r.Cursor())
+
+	d := &Process{ID: "d", SizeInKB: 2}
+	assert.NoError(t, r.Allocate(d))
+	assert.Equal(t, 16, d.MemoryAddress)
+	assert.Equal(t, 18, r.Cursor())
+
+	// The search goes forward from the cursor even if there's space before it
+	r.Memory.ReleaseProcess(c)
+	e := &Process{ID: "e", SizeInKB: 2}
+	assert.NoError(t, r.Allocate(e))
+	assert.Equal(t, 18, e.MemoryAddress)
+	assert.Equal(t, 0, r.Cursor(), "Filling the last cell wraps the cursor")
+
+	assert.Equal(t, ErrNoContiguousSpace, r.Allocate(&Process{ID: "f", SizeInKB: 9}))
+	assert.Equal(t, ErrNilProcess, r.Allocate(nil))
+	f := &Process{ID: "f", SizeInKB: 1}
+	assert.NoError(t, r.Allocate(f))
+	assert.Equal(t, 0, f.MemoryAddress)
+	for _, p := range []*Process{a, b, c, d, e, f} {
+		if p.IsAllocated {
+			assert.True(t, p.MemoryAddress+p.SizeInKB <= 20)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package dino
+
+import "fmt"
+
+// AllocateScatter places p in the first hole it fits in like first fit, or, when no hole is large enough, scatters it
+// across the holes in address order until it's whole. Returns the blocks p occupies, which are kept in p.Fragments
+// when there's more than one so that ReleaseProcess frees them all. Scattered processes can't be compacted
+func (m Memory) AllocateScatter(p *Process) ([]MemoryBlock, error) {
+	if p == nil {
+		return nil, ErrNilProcess
+	} else if p.IsAllocated {
+		return nil, ErrAlreadyAllocated
+	} else if p.ID == "" {
+		return nil, ErrMissingID
+	}
+	reachable := m.Reachable(p)
+	if start, _, err := reachable.FirstFit(p.SizeInKB); err == nil {
+		if err := m.Allocate(p, start); err != nil {
+			return nil, err
+		}
+		return []MemoryBlock{{Start: start, Size: p.SizeInKB, Name: p.Name, OwnerID: p.ID}}, nil
+	} else if free := reachable.TotalFree(); free < p.SizeInKB {
+		return nil, fmt.Errorf("Cannot allocate -- %d KB needed but only %d KB are free", p.SizeInKB, free)
+	}
+
+	fragments := []MemoryBlock{}
+	left := p.SizeInKB
+	for _, hole := range reachable.holes() {
+		size := hole.Size
+		if size > left {
+			size = left
+		}
+		for i := hole.Start; i < hole.Start+size; i++ {
+			m[i] = p
+		}
+		fragments = append(fragments, MemoryBlock{Start: hole.Start, Size: size, Name: p.Name, OwnerID: p.ID})
+		if left -= size; left == 0 {
+			break
+		}
+	}
+	p.IsAllocated = true
+	p.MemoryAddress = fragments[0].Start
+	p.Fragments = fragments
+	return append([]MemoryBlock{}, fragments...), nil
+}
+
+// releaseFragments frees every block of a scattered process, checking first that they're all still its own
+func (m Memory) releaseFragments(p *Process) (bool, error) {
+	for _, fragment := range p.Fragments {
+		if err := m.checkBounds(fragment.Start, fragment.Size); err != nil {
+			return false, err
+		}
+		for i := fragment.Start; i < fragment.Start+fragment.Size; i++ {
+			if m[i] != p {
+				return false, fmt.Errorf("Unsafe delete -- cell %d of a fragment of process '%s' isn't its own", i, p.ID)
+			}
+		}
+	}
+	for _, fragment := range p.Fragments {
+		for i := fragment.Start; i < fragment.Start+fragment.Size; i++ {
+			m[i] = nil
+		}
+	}
+	p.IsAllocated = false
+	p.MemoryAddress = -1
+	p.Fragments = nil
+	return true, nil
+}
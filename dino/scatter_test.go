@@ -0,0 +1,130 @@
+package dino
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllocateScatter(t *testing.T) {
+	m := make(Memory, 30)
+	a := &Process{ID: "a", SizeInKB: 10, MemoryAddress: -1}
+	b := &Process{ID: "b", SizeInKB: 5, MemoryAddress: -1}
+	m.Allocate(a, 5)
+	m.Allocate(b, 20)
+
+	// Holes of 5, 5 and 5 cells: no single one fits 8
+	p := &Process{ID: "p", Name: "p", SizeInKB: 8, MemoryAddress: -1}
+	fragments, err := m.AllocateScatter(p)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemoryBlock{{Start: 0, Size: 5, Name: "p", OwnerID: "p"}, {Start: 15, Size: 3, Name: "p", OwnerID: "p"}}, fragments)
+	assert.Equal(t, fragments, p.Fragments)
+	assert.Equal(t, 0, p.MemoryAddress)
+	assert.Equal(t, 7, m.TotalFree())
+	assert.True(t, p.Clone().Equal(p))
+
+	released, err := m.ReleaseProcess(p)
+	assert.NoError(t, err)
+	assert.True(t, released)
+	assert.Equal(t, 15, m.TotalFree())
+	assert.Nil(t, p.Fragments)
+	assert.False(t, p.IsAllocated)
+	for i := range m {
+		assert.True(t, m[i] == nil || m[i] == a || m[i] == b, "Cell %d", i)
+	}
+
+	// A hole that fits keeps it contiguous
+	q := &Process{ID: "q", Name: "q", SizeInKB: 4, MemoryAddress: -1}
+	fragments, err = m.AllocateScatter(q)
+	assert.NoError(t, err)
+	assert.Len(t, fragments, 1)
+	assert.Nil(t, q.Fragments)
+
+	_, err = m.AllocateScatter(&Process{ID: "r", SizeInKB: 12, MemoryAddress: -1})
+	assert.EqualError(t, err, "Cannot allocate -- 12 KB needed but only 11 KB are free")
+}
+
+// scatteredMemory holds a at 5 and b at 20, with p scattered across [0, 5) and [15, 18)
+func scatteredMemory(t *testing.T) (m Memory, a, b, p *Process) {
+	m = make(Memory, 30)
+	a = &Process{ID: "a", Name: "a", SizeInKB: 10, MemoryAddress: -1}
+	b = &Process{ID: "b", Name: "b", SizeInKB: 5, MemoryAddress: -1}
+	p = &Process{ID: "p", Name: "p", SizeInKB: 8, MemoryAddress: -1}
+	assert.NoError(t, m.Allocate(a, 5))
+	assert.NoError(t, m.Allocate(b, 20))
+	_, err := m.AllocateScatter(p)
+	assert.NoError(t, err)
+	return m, a, b, p
+}
+
+func TestScatteredProcessesStayInPlace(t *testing.T) {
+	m, a, b, p := scatteredMemory(t)
+	layout := m.Layout()
+	assert.Error(t, m.Reallocate(p, 9))
+	assert.Error(t, m.Reallocate(p, 3))
+	assert.Error(t, m.ApplyPlan([]Relocation{{Process: p, From: 0, To: 25}}))
+	assert.Equal(t, layout, m.Layout(), "Neighbors are untouched")
+	assert.Equal(t, 8, p.SizeInKB)
+
+	left, right := m.NeighborsOf(p)
+	assert.Equal(t, []int{0, 0}, []int{left, right})
+
+	// p can't be evicted: the largest hole that can be made is the 12 cells after it
+	_, err := m.EvictFor(13)
+	assert.Error(t, err)
+	assert.True(t, a.IsAllocated && b.IsAllocated && p.IsAllocated, "Nothing is evicted")
+	evicted, err := m.EvictFor(12)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Process{b}, evicted)
+	assert.True(t, p.IsAllocated)
+}
+
+func TestFreeListReleaseScattered(t *testing.T) {
+	m, _, b, p := scatteredMemory(t)
+	list := NewFreeListMemory(m)
+	_, err := list.ReleaseProcess(b)
+	assert.NoError(t, err)
+	_, err = list.ReleaseProcess(p)
+	assert.NoError(t, err)
+
+	fresh := NewFreeListMemory(list.Memory)
+	assert.Equal(t, fresh.tree, list.tree, "Every fragment is its own hole")
+	assert.Equal(t, 20, list.TotalFree())
+	assert.Equal(t, fresh.HoleCount(), list.HoleCount())
+	start, size, err := list.Fit(15, FS_FIRST_FIT)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{15, 15}, []int{start, size})
+}
+
+func TestCompactAroundScattered(t *testing.T) {
+	m, a, b, p := scatteredMemory(t)
+	assert.Equal(t, []Relocation{{Process: b, From: 20, To: 18}}, m.CompactionPlan(), "b closes the gap after p's second fragment")
+	moved, err := m.Compact()
+	assert.NoError(t, err)
+	assert.Equal(t, []*Process{b}, moved)
+	assert.Equal(t, []MemoryBlock{{Start: 0, Size: 5, Name: "p", OwnerID: "p"}, {Start: 15, Size: 3, Name: "p", OwnerID: "p"}}, p.Fragments)
+	assert.Equal(t, 5, a.MemoryAddress)
+	assert.Equal(t, 7, m.LargestFreeBlock())
+
+	m, _, b, _ = scatteredMemory(t)
+	moved, err = m.CompactEnd()
+	assert.NoError(t, err)
+	assert.Equal(t, []*Process{b}, moved)
+	assert.Equal(t, 25, b.MemoryAddress)
+
+	m, _, b, _ = scatteredMemory(t)
+	moved, err = m.CompactSmall(5)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Process{b}, moved)
+	assert.Equal(t, 18, b.MemoryAddress)
+
+	// Compaction can make a hole of 7 KB around p, not one of 9 KB
+	m, _, _, _ = scatteredMemory(t)
+	assert.True(t, m.CanFitAll([]*Process{{ID: "q", SizeInKB: 7}}))
+	assert.False(t, m.CanFitAll([]*Process{{ID: "q", SizeInKB: 9}}))
+	q := &Process{ID: "q", Name: "q", SizeInKB: 7, MemoryAddress: -1}
+	compacted, err := m.AllocateOrCompact(q, FS_FIRST_FIT)
+	assert.NoError(t, err)
+	assert.True(t, compacted)
+	assert.Equal(t, 23, q.MemoryAddress)
+}
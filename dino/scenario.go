@@ -0,0 +1,94 @@
+package dino
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type AssertionKind string
+
+const (
+	AK_FREE_MEMORY = AssertionKind("Free memory")
+	AK_TERMINATED  = AssertionKind("Terminated")
+)
+
+// Assertion is a check on the state of the Dino at the end of a step of a scenario
+type Assertion struct {
+	Kind       AssertionKind
+	Step       int
+	FreeMemory int    // KB expected to be free at the end of Step, for AK_FREE_MEMORY
+	ProcessID  string // process expected to have terminated by the end of Step, for AK_TERMINATED
+}
+
+// ExpectFreeMemory asserts that kb KB are free at the end of step
+func ExpectFreeMemory(step, kb int) Assertion {
+	return Assertion{Kind: AK_FREE_MEMORY, Step: step, FreeMemory: kb}
+}
+
+// ExpectTerminatedBy asserts that the process with the given ID has terminated by the end of step
+func ExpectTerminatedBy(id string, step int) Assertion {
+	return Assertion{Kind: AK_TERMINATED, Step: step, ProcessID: id}
+}
+
+// check returns why the assertion doesn't hold for d, or nil if it does
+func (a Assertion) check(d *Dino) error {
+	switch a.Kind {
+	case AK_FREE_MEMORY:
+		if free := d.Memory.TotalFree(); free != a.FreeMemory {
+			return fmt.Errorf("Assertion failed -- at step %d, expected %d KB free but got %d KB", a.Step, a.FreeMemory, free)
+		}
+	case AK_TERMINATED:
+		p, location, err := d.ProcessByID(a.ProcessID)
+		if err != nil || location != "Terminated" || p.FinishStep > a.Step {
+			return fmt.Errorf("Assertion failed -- process '%s' didn't terminate by step %d", a.ProcessID, a.Step)
+		}
+	default:
+		return fmt.Errorf("Assertion failed -- unknown kind '%s'", a.Kind)
+	}
+	return nil
+}
+
+// RunScenarioAssert runs the scenario read from r, written in the commands of RunInteractiveText, on the Dino they
+// build, checking every assertion when it reaches the end of its step. Returns the first that fails, in step order, or
+// an error for the first command that fails or the assertions whose step the scenario never reached
+func RunScenarioAssert(r io.Reader, assertions []Assertion) error {
+	d := newTextDino(TEXT_MEMORY_SIZE, 0)
+	pending := append([]Assertion{}, assertions...)
+	sort.SliceStable(pending, func(i, j int) bool { return pending[i].Step < pending[j].Step })
+	check := func() error {
+		for len(pending) > 0 && pending[0].Step <= d.step {
+			if pending[0].Step == d.step {
+				if err := pending[0].check(d); err != nil {
+					return err
+				}
+			}
+			pending = pending[1:]
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		next, quit, err := d.runCommand(scanner.Text())
+		d = next
+		if err != nil {
+			return fmt.Errorf("Cannot run scenario -- line %d: %s", line, err.Error())
+		} else if err := check(); err != nil {
+			return err
+		} else if quit {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	} else if len(pending) > 0 {
+		return fmt.Errorf("Assertion failed -- the scenario ended at step %d, before step %d", d.step, pending[0].Step)
+	}
+	return nil
+}
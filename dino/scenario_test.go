@@ -0,0 +1,30 @@
+package dino
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunScenarioAssert(t *testing.T) {
+	const scenario = "memory 100 1\ninject a 10 4\ninject b 20 4\nstep\nstep\nstep\nstep\n"
+	run := func(assertions ...Assertion) error {
+		return RunScenarioAssert(strings.NewReader(scenario), assertions)
+	}
+
+	assert.NoError(t, run(ExpectTerminatedBy("a", 3), ExpectFreeMemory(1, 70), ExpectFreeMemory(4, 100)))
+
+	err := run(ExpectFreeMemory(1, 70), ExpectTerminatedBy("b", 3), ExpectFreeMemory(2, 0))
+	assert.EqualError(t, err, "Assertion failed -- at step 2, expected 0 KB free but got 70 KB", "The first failure by step")
+	assert.EqualError(t, run(ExpectTerminatedBy("b", 3)), "Assertion failed -- process 'b' didn't terminate by step 3")
+	assert.EqualError(t, run(ExpectTerminatedBy("c", 3)), "Assertion failed -- process 'c' didn't terminate by step 3")
+	assert.EqualError(t, run(ExpectFreeMemory(9, 100)), "Assertion failed -- the scenario ended at step 4, before step 9")
+
+	err = RunScenarioAssert(strings.NewReader("step\nfly\n"), nil)
+	assert.EqualError(t, err, "Cannot run scenario -- line 2: Unknown command -- 'fly'")
+
+	// Without memory, the scenario runs on the default Dino
+	err = RunScenarioAssert(strings.NewReader("inject a 10 4\nstep\n"), []Assertion{ExpectFreeMemory(1, TEXT_MEMORY_SIZE-10)})
+	assert.NoError(t, err)
+}
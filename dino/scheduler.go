@@ -9,6 +9,25 @@ type Scheduler interface {
 	Len() int
 	Name() string
 	String() []string
+	Processes() Processes // Processes returns the queued processes, without deletion, in the order they should be re-added
+}
+
+// Remover is implemented by schedulers that can drop a queued process in place, without touching the others
+type Remover interface {
+	Remove(p *Process) bool // reports whether p was queued
+}
+
+// removeFrom takes p out of s, reporting false when p is queued in s but s can't drop it
+func removeFrom(s Scheduler, p *Process) bool {
+	if r, ok := s.(Remover); ok && r.Remove(p) {
+		return true
+	}
+	return !s.Processes().contains(p)
+}
+
+// Reasoner is implemented by schedulers that can explain their choices, for the schedule trace
+type Reasoner interface {
+	Reason() string // why the last process returned by Get was picked
 }
 
 // Dispatcher: Se encarga de mover procesos de la cola de Ready hacia el CPU para su ejecución (realiza el cambio de contexto)
@@ -0,0 +1,109 @@
+package dino
+
+import (
+	"errors"
+	"fmt"
+)
+
+// TieBreaker reports whether a should be picked before b when a scheduler finds them otherwise equal.
+// A nil TieBreaker keeps arrival order (FIFO)
+type TieBreaker func(a, b *Process) bool
+
+// LIFOTieBreak picks the most recently added process among equals
+func LIFOTieBreak(a, b *Process) bool {
+	return true
+}
+
+// IDTieBreak picks the process with the lowest ID among equals
+func IDTieBreak(a, b *Process) bool {
+	return a.ID < b.ID
+}
+
+// ShortestJobFirst is a scheduler that always picks the process with the fewest remaining bursts
+type ShortestJobFirst struct {
+	name     string
+	queue    *ProcessHeap
+	arrival  map[*Process]int
+	arrived  int
+	reason   string
+	TieBreak TieBreaker
+}
+
+func NewShortestJobFirst(name string, tieBreak TieBreaker) *ShortestJobFirst {
+	s := &ShortestJobFirst{name: name, arrival: map[*Process]int{}, TieBreak: tieBreak}
+	s.queue = NewProcessHeap(s.less)
+	return s
+}
+
+// less reports whether a should run before b
+func (s *ShortestJobFirst) less(a, b *Process) bool {
+	if a.RemainingBursts() != b.RemainingBursts() {
+		return a.RemainingBursts() < b.RemainingBursts()
+	} else if s.TieBreak == nil {
+		return false // the heap keeps arrival order
+	} else if s.arrival[a] > s.arrival[b] {
+		return s.TieBreak(a, b)
+	}
+	return !s.TieBreak(b, a)
+}
+
+func (s *ShortestJobFirst) Add(p *Process) error {
+	if p == nil {
+		return errors.New("Cannot add nil process")
+	}
+	s.arrival[p] = s.arrived
+	s.arrived++
+	s.queue.Push(p)
+	return nil
+}
+
+func (s *ShortestJobFirst) Get() (*Process, error) {
+	ready := s.queue.Len()
+	p := s.queue.Pop()
+	if p == nil {
+		return nil, errors.New("Nothing to return")
+	}
+	delete(s.arrival, p)
+	s.reason = fmt.Sprintf("%d remaining bursts, the fewest of %d ready", p.RemainingBursts(), ready)
+	return p, nil
+}
+
+func (s *ShortestJobFirst) Remove(p *Process) bool {
+	if !s.queue.Remove(p) {
+		return false
+	}
+	delete(s.arrival, p)
+	return true
+}
+
+func (s *ShortestJobFirst) Reason() string {
+	return s.reason
+}
+
+func (s *ShortestJobFirst) Read() (*Process, error) {
+	p := s.queue.Peek()
+	if p == nil {
+		return nil, errors.New("Nothing to return")
+	}
+	return p, nil
+}
+
+func (s *ShortestJobFirst) Len() int {
+	return s.queue.Len()
+}
+
+func (s *ShortestJobFirst) Name() string {
+	return s.name
+}
+
+func (s *ShortestJobFirst) Processes() Processes {
+	return s.queue.Processes()
+}
+
+func (s *ShortestJobFirst) String() []string {
+	stringSlice := []string{}
+	for _, p := range s.Processes() {
+		stringSlice = append(stringSlice, fmt.Sprintf(" ['%s', %2d, %2dKB] %.3s ", p.Name, p.RemainingBursts(), p.SizeInKB, s.name))
+	}
+	return stringSlice
+}
@@ -0,0 +1,90 @@
+package dino
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShortestJobFirst(t *testing.T) {
+	long := testProcess()
+	short := testProcess()
+	short.Bursts = short.Bursts[:2]
+
+	sjf := NewShortestJobFirst("SJF", nil)
+	assert.NoError(t, sjf.Add(long))
+	assert.NoError(t, sjf.Add(short))
+	assert.Error(t, sjf.Add(nil))
+	assert.Equal(t, 2, sjf.Len())
+
+	p, err := sjf.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, short, p)
+	assert.Equal(t, 2, sjf.Len())
+
+	p, err = sjf.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, short, p)
+	p, err = sjf.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, long, p)
+
+	_, err = sjf.Get()
+	assert.Error(t, err)
+}
+
+func TestShortestJobFirstRemove(t *testing.T) {
+	long, short, shortest := testProcess(), testProcess(), testProcess()
+	short.Bursts = short.Bursts[:2]
+	shortest.Bursts = shortest.Bursts[:1]
+	sjf := NewShortestJobFirst("SJF", IDTieBreak)
+	sjf.Add(long)
+	sjf.Add(short)
+	sjf.Add(shortest)
+
+	assert.True(t, sjf.Remove(shortest))
+	assert.False(t, sjf.Remove(shortest))
+	assert.Equal(t, Processes{long, short}, sjf.Processes())
+	assert.Len(t, sjf.arrival, 2)
+	p, _ := sjf.Get()
+	assert.Equal(t, short, p)
+}
+
+func TestTieBreaker(t *testing.T) {
+	first := testProcess()
+	first.ID = "b"
+	second := testProcess()
+	second.ID = "a"
+
+	picks := map[string]TieBreaker{"b": nil, "a": LIFOTieBreak}
+	for expected, tieBreak := range picks {
+		sjf := NewShortestJobFirst("SJF", tieBreak)
+		sjf.Add(first)
+		sjf.Add(second)
+		p, err := sjf.Get()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, p.ID)
+	}
+
+	sjf := NewShortestJobFirst("SJF", IDTieBreak)
+	sjf.Add(first)
+	sjf.Add(second)
+	p, _ := sjf.Get()
+	assert.Equal(t, "a", p.ID)
+}
+
+func TestScheduleTraceSJF(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	d.DisableIO = true
+	assert.NoError(t, d.SetScheduler(NewShortestJobFirst("SJF", nil)))
+	long := &Process{ID: "long", Name: "long", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1}
+	short := &Process{ID: "short", Name: "short", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: Bursts{BT_CPU, BT_CPU, BT_CPU, BT_CPU}, MemoryAddress: -1}
+	d.Inject(long)
+	d.Inject(short)
+	d.Step()
+
+	trace := d.ScheduleTrace()
+	assert.Equal(t, "[   1] CPU      short: 4 remaining bursts, the fewest of 2 ready\n", trace)
+	assert.Equal(t, "4 remaining bursts, the fewest of 2 ready", d.Events()[2].Detail, "The reason is in the event log")
+}
@@ -0,0 +1,365 @@
+package dino
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+)
+
+// snapshot is the serializable form of a Dino. Every process is stored once, and referenced by ID everywhere else,
+// so that Load can rebuild the pointer sharing between memory cells, queues and executors.
+type snapshot struct {
+	MemorySize      int
+	DisableIO       bool
+	DisableArrivals bool
+	StallThreshold  int
+	Step            int
+	NoProgress      int
+	Events          []Event
+	Metrics         Metrics
+	Seed            int64
+	Draws           uint64
+	TouchDraws      uint64
+	Scheduler       string
+	SchedulerKind   string
+	SwitchSteps     int
+	LastRun         string
+	Switching       string
+	Terminated      []string
+	RecordTimeline  bool
+	BurstRules      BurstRules
+	NumIODevices    int
+	Bandwidth       int
+	Prioritize      bool
+	Boosted         []string
+	Arriving        []string
+	Parents         map[string]string // child ID -> parent ID, processes are saved without their parent
+	Timeline        [][]rune
+	SwitchLeft      int
+	FragAware       bool // the new queue is a FragAwareScheduler rather than FCFS
+	KeepHistory     bool // the history itself isn't saved
+	Poisson         bool
+	Lambda          float64
+
+	Processes            []Process
+	Memory               []string // process ID per cell, "" for free cells
+	NewQ                 []string
+	ReadyQ               []string
+	ExecutedByCPU        string
+	ExecutedByIO         []string
+	FragmentationProcess string
+	Message              string
+	Paging               *pagingSnapshot
+}
+
+type pagingSnapshot struct {
+	ResidentSetSize int
+	Policy          string
+	Resident        map[string][]int
+	Faults          map[string]int
+}
+
+// Save serializes the whole simulator so it can be resumed later with Load. It fails, writing nothing, when a scheduler
+// or the replacement policy is one Load can't rebuild
+func (d *Dino) Save(w io.Writer) error {
+	kind, err := schedulerKind(d.readyQueue)
+	if err != nil {
+		return err
+	}
+	_, fragAware := d.newQueue.(*FragAwareScheduler)
+	if _, fcfs := d.newQueue.(*Queue); !fcfs && !fragAware {
+		return fmt.Errorf("Cannot save -- admission scheduler '%s' keeps state that can't be saved", d.newQueue.Name())
+	} else if d.Paging != nil {
+		if err := checkReplacementPolicy(d.Paging.Policy); err != nil {
+			return err
+		}
+	}
+	s := snapshot{
+		MemorySize:      d.memorySize,
+		DisableIO:       d.DisableIO,
+		DisableArrivals: d.DisableArrivals,
+		StallThreshold:  d.StallThreshold,
+		Step:            d.step,
+		NoProgress:      d.noProgress,
+		Events:          d.events,
+		Metrics:         d.metrics,
+		Seed:            d.source.seed,
+		Draws:           d.source.draws,
+		TouchDraws:      d.touches.draws,
+		Scheduler:       d.readyQueue.Name(),
+		SchedulerKind:   kind,
+		SwitchSteps:     d.ContextSwitchSteps,
+		LastRun:         d.lastRun,
+		SwitchLeft:      d.switchLeft,
+		RecordTimeline:  d.RecordTimeline,
+		BurstRules:      d.BurstRules,
+		NumIODevices:    d.NumIODevices,
+		Bandwidth:       d.MemoryBandwidth,
+		Prioritize:      d.PrioritizeInteractive,
+		FragAware:       fragAware,
+		KeepHistory:     d.KeepHistory,
+		Poisson:         d.poisson,
+		Lambda:          d.lambda,
+		Timeline:        d.timeline,
+		Memory:          make([]string, len(d.Memory)),
+		Message:         d.state.Message,
+	}
+
+	seen := map[string]*Process{}
+	var register func(p *Process) string
+	register = func(p *Process) string {
+		if p == nil || err != nil {
+			return ""
+		} else if isReservation(p) {
+			return p.ID
+		}
+		if p.ID == "" {
+			err = fmt.Errorf("Cannot save -- process '%s' has no ID", p.Name)
+		} else if known, ok := seen[p.ID]; ok && known != p {
+			err = fmt.Errorf("Cannot save -- more than one process has ID '%s'", p.ID)
+		} else if !ok {
+			seen[p.ID] = p
+			saved := *p
+			saved.Parent = nil
+			s.Processes = append(s.Processes, saved)
+			if p.Parent != nil { // saved even when it's no longer in the Dino
+				if s.Parents == nil {
+					s.Parents = map[string]string{}
+				}
+				s.Parents[p.ID] = register(p.Parent)
+			}
+		}
+		return p.ID
+	}
+
+	for i := range d.Memory {
+		s.Memory[i] = register(d.Memory[i])
+	}
+	for _, p := range d.newQueue.Processes() {
+		s.NewQ = append(s.NewQ, register(p))
+	}
+	for _, p := range d.readyQueue.Processes() {
+		s.ReadyQ = append(s.ReadyQ, register(p))
+	}
+	s.ExecutedByCPU = register(d.state.ExecutedByCPU)
+	for _, p := range d.state.ExecutedByIO {
+		s.ExecutedByIO = append(s.ExecutedByIO, register(p))
+	}
+	s.FragmentationProcess = register(d.state.FragmentationProcess)
+	s.Switching = register(d.switching)
+	for _, p := range d.boosted {
+		s.Boosted = append(s.Boosted, register(p))
+	}
+	for _, p := range d.arriving {
+		s.Arriving = append(s.Arriving, register(p))
+	}
+	for _, p := range d.terminated {
+		s.Terminated = append(s.Terminated, register(p))
+	}
+	if err != nil {
+		return err
+	}
+	if d.Paging != nil {
+		s.Paging = &pagingSnapshot{ResidentSetSize: d.Paging.ResidentSetSize, Policy: d.Paging.Policy.Name(), Resident: d.Paging.resident, Faults: d.Paging.faults}
+	}
+
+	return gob.NewEncoder(w).Encode(s)
+}
+
+// Load rebuilds a Dino previously serialized with Save
+func Load(r io.Reader) (*Dino, error) {
+	var s snapshot
+	if err := gob.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+
+	d := NewSeeded(s.MemorySize, s.Seed)
+	var err error
+	if d.readyQueue, err = newSchedulerOfKind(s.SchedulerKind, s.Scheduler); err != nil {
+		return nil, err
+	} else if len(s.Memory) != s.MemorySize {
+		return nil, fmt.Errorf("Cannot load -- memory has %d cells but its size is %d", len(s.Memory), s.MemorySize)
+	}
+	d.DisableIO = s.DisableIO
+	d.DisableArrivals = s.DisableArrivals
+	d.StallThreshold = s.StallThreshold
+	d.step = s.Step
+	d.noProgress = s.NoProgress
+	d.events = s.Events
+	d.metrics = s.Metrics
+	d.source.restore(s.Seed, s.Draws)
+	d.touches.restore(touchSeed(s.Seed), s.TouchDraws)
+	d.ContextSwitchSteps = s.SwitchSteps
+	d.lastRun = s.LastRun
+	d.switchLeft = s.SwitchLeft
+	d.RecordTimeline = s.RecordTimeline
+	d.BurstRules = s.BurstRules
+	d.NumIODevices = s.NumIODevices
+	d.MemoryBandwidth = s.Bandwidth
+	d.PrioritizeInteractive = s.Prioritize
+	d.timeline = s.Timeline
+	d.KeepHistory = s.KeepHistory
+	d.poisson, d.lambda = s.Poisson, s.Lambda
+	if s.FragAware {
+		d.newQueue = NewFragAwareScheduler(d.newQueue.Name(), &d.Memory)
+	}
+
+	processes := map[string]*Process{}
+	for i := range s.Processes {
+		p := s.Processes[i]
+		processes[p.ID] = &p
+	}
+	for child, parent := range s.Parents {
+		processes[child].Parent = processes[parent]
+	}
+	lookup := func(id string) *Process {
+		if id == "" || err != nil {
+			return nil
+		} else if id == reserved.ID {
+			return reserved
+		} else if id == reservedTail.ID {
+			return reservedTail
+		}
+		p, ok := processes[id]
+		if !ok {
+			err = fmt.Errorf("Cannot load -- unknown process '%s'", id)
+		}
+		return p
+	}
+
+	for i := range s.Memory {
+		d.Memory[i] = lookup(s.Memory[i])
+	}
+	for _, id := range s.NewQ {
+		if p := lookup(id); p != nil {
+			d.newQueue.Add(p)
+		}
+	}
+	for _, id := range s.ReadyQ {
+		if p := lookup(id); p != nil {
+			d.readyQueue.Add(p)
+		}
+	}
+	d.state.ExecutedByCPU = lookup(s.ExecutedByCPU)
+	for _, id := range s.ExecutedByIO {
+		d.state.ExecutedByIO = append(d.state.ExecutedByIO, lookup(id))
+	}
+	d.state.FragmentationProcess = lookup(s.FragmentationProcess)
+	d.switching = lookup(s.Switching)
+	for _, id := range s.Arriving {
+		d.arriving = append(d.arriving, lookup(id))
+	}
+	for _, id := range s.Boosted {
+		d.boosted = append(d.boosted, lookup(id))
+	}
+	for _, id := range s.Terminated {
+		d.terminated = append(d.terminated, lookup(id))
+	}
+	d.state.Message = s.Message
+	if err != nil {
+		return nil, err
+	}
+	if s.Paging != nil {
+		d.Paging = NewPagedMemory(s.Paging.ResidentSetSize)
+		if d.Paging.Policy, err = replacementPolicyByName(s.Paging.Policy); err != nil {
+			return nil, err
+		}
+		for id, pages := range s.Paging.Resident {
+			d.Paging.resident[id] = pages
+		}
+		for id, faults := range s.Paging.Faults {
+			d.Paging.faults[id] = faults
+		}
+	}
+
+	d.stampProcesses()
+	d.refreshState()
+	return d, nil
+}
+
+// Clone returns an independent deep copy of the Dino, so both can be stepped without affecting each other. It fails
+// like Save does for the Dinos Save can't serialize
+func (d *Dino) Clone() (*Dino, error) {
+	var buf bytes.Buffer
+	if err := d.Save(&buf); err != nil {
+		return nil, fmt.Errorf("Cannot clone -- %s", err)
+	}
+	clone, err := Load(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot clone -- %s", err)
+	}
+	return clone, nil
+}
+
+// recordHistory saves the Dino as it is at the current step
+func (d *Dino) recordHistory() error {
+	var buf bytes.Buffer
+	if err := d.Save(&buf); err != nil {
+		return err
+	}
+	if d.history == nil {
+		d.history = map[int][]byte{}
+	}
+	d.history[d.step] = buf.Bytes()
+	return nil
+}
+
+// RewindTo restores the Dino as it was at the end of an earlier step, saved while KeepHistory was set. The steps after
+// it are forgotten, so stepping again records the new ones in their place
+func (d *Dino) RewindTo(step int) error {
+	saved, ok := d.history[step]
+	if !ok {
+		return fmt.Errorf("Cannot rewind -- step %d is not in the history", step)
+	}
+	rewound, err := Load(bytes.NewReader(saved))
+	if err != nil {
+		return err
+	}
+
+	history := d.history
+	for s := range history {
+		if s > step {
+			delete(history, s)
+		}
+	}
+	middleware := d.middleware
+	*d = *rewound
+	d.history = history
+	d.middleware = middleware
+	if fragAware, ok := d.newQueue.(*FragAwareScheduler); ok {
+		fragAware.memory = &d.Memory
+	}
+	return nil
+}
+
+// schedulerKind identifies the schedulers Load can rebuild from the order of their processes alone
+func schedulerKind(s Scheduler) (string, error) {
+	switch s := s.(type) {
+	case *MultilevelQueue:
+		if s.Name() == newReadyQueue().Name() {
+			return "Ready Multilevel", nil
+		}
+	case *Queue:
+		return "FCFS", nil
+	case *ShortestJobFirst:
+		if s.TieBreak == nil {
+			return "SJF", nil
+		}
+	}
+	return "", fmt.Errorf("Cannot save -- scheduler '%s' keeps state that can't be saved", s.Name())
+}
+
+func newSchedulerOfKind(kind, name string) (Scheduler, error) {
+	switch kind {
+	case "Ready Multilevel":
+		if ready := newReadyQueue(); ready.Name() == name {
+			return ready, nil
+		}
+	case "FCFS":
+		return NewQueue(name), nil
+	case "SJF":
+		return NewShortestJobFirst(name, nil), nil
+	}
+	return nil, fmt.Errorf("Cannot load -- unknown scheduler '%s'", name)
+}
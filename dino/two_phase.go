@@ -0,0 +1,92 @@
+package dino
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// placeholderPrefix starts the ID of the placeholders that hold the holes of Reserve2P, so they can't be taken for
+// the processes they're held for
+const placeholderPrefix = "\x00pending "
+
+// isPlaceholder reports whether p holds a hole for a Reservation rather than being a process
+func isPlaceholder(p *Process) bool {
+	return p != nil && strings.HasPrefix(p.ID, placeholderPrefix)
+}
+
+// errPlaceholder is returned when releasing the cells of a Reservation, which only Abort frees
+var errPlaceholder = errors.New("Cannot release -- the cells are held by a reservation, abort it instead")
+
+// Reservation is a hole held by Reserve2P for a process, until it's committed or aborted. It belongs to the caller
+// of Reserve2P, who must end it with either Commit or Abort
+type Reservation struct {
+	Start       int
+	memory      Memory
+	p           *Process
+	placeholder *Process
+}
+
+// Reserve2P is the first phase of a two-phase allocation: it finds a hole for p following strategy and fills it with
+// a placeholder, so no other allocation can take it and compaction doesn't move it, until Commit allocates p there
+// or Abort frees it
+func (m Memory) Reserve2P(p *Process, strategy FitStrategy) (*Reservation, error) {
+	if p == nil {
+		return nil, ErrNilProcess
+	} else if p.IsAllocated {
+		return nil, ErrAlreadyAllocated
+	} else if p.ID == "" {
+		return nil, ErrMissingID
+	}
+	start, _, err := m.Reachable(p).Fit(p.SizeInKB, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	placeholder := &Process{ID: placeholderPrefix + p.ID, Name: p.Name, SizeInKB: p.SizeInKB, MemoryAddress: start}
+	for i := start; i < start+p.SizeInKB; i++ {
+		m[i] = placeholder
+	}
+	return &Reservation{Start: start, memory: m, p: p, placeholder: placeholder}, nil
+}
+
+// held reports whether the hole of r is still filled with its placeholder
+func (r *Reservation) held() bool {
+	if r.placeholder == nil {
+		return false
+	}
+	for i := r.Start; i < r.Start+r.placeholder.SizeInKB; i++ {
+		if r.memory[i] != r.placeholder {
+			return false
+		}
+	}
+	return true
+}
+
+// Commit allocates the process the hole was reserved for. It fails, keeping the hole held, when the process was
+// allocated somewhere else in the meantime
+func (r *Reservation) Commit() error {
+	if !r.held() {
+		return fmt.Errorf("Cannot commit -- no hole is held at %d", r.Start)
+	} else if r.p.IsAllocated {
+		return ErrAlreadyAllocated
+	}
+	for i := r.Start; i < r.Start+r.placeholder.SizeInKB; i++ {
+		r.memory[i] = r.p
+	}
+	r.p.IsAllocated = true
+	r.p.MemoryAddress = r.Start
+	r.placeholder = nil
+	return nil
+}
+
+// Abort frees the hole. Committed and aborted reservations are ignored
+func (r *Reservation) Abort() {
+	if !r.held() {
+		return
+	}
+	for i := r.Start; i < r.Start+r.placeholder.SizeInKB; i++ {
+		r.memory[i] = nil
+	}
+	r.placeholder = nil
+}
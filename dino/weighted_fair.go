@@ -0,0 +1,115 @@
+package dino
+
+import (
+	"errors"
+	"fmt"
+)
+
+type wfqEntry struct {
+	process     *Process
+	virtualTime float64
+	arrival     int
+}
+
+// WFQScheduler shares the CPU proportionally to process weights, biased by their nice values. Every time a process runs
+// it's charged 1/ShareWeight of virtual time, and the process with the lowest virtual time is always picked next (oldest first among equals)
+type WFQScheduler struct {
+	name    string
+	entries []wfqEntry
+	clock   float64            // virtual time of the last dispatched process
+	charged map[string]float64 // virtual time of dispatched processes, until they're added back
+	arrived int
+	reason  string
+}
+
+func NewWFQScheduler(name string) *WFQScheduler {
+	return &WFQScheduler{name: name, charged: map[string]float64{}}
+}
+
+func (s *WFQScheduler) Add(p *Process) error {
+	if p == nil {
+		return errors.New("Cannot add nil process")
+	}
+	virtualTime, ok := s.charged[p.ID]
+	if ok {
+		delete(s.charged, p.ID)
+	} else {
+		virtualTime = s.clock // newcomers don't get credit for the time they weren't here
+	}
+	s.entries = append(s.entries, wfqEntry{process: p, virtualTime: virtualTime, arrival: s.arrived})
+	s.arrived++
+	return nil
+}
+
+// pick returns the index of the next entry to run, or -1 if there are none
+func (s *WFQScheduler) pick() int {
+	best := -1
+	for i, e := range s.entries {
+		if best == -1 || e.virtualTime < s.entries[best].virtualTime ||
+			(e.virtualTime == s.entries[best].virtualTime && e.arrival < s.entries[best].arrival) {
+			best = i
+		}
+	}
+	return best
+}
+
+func (s *WFQScheduler) Get() (*Process, error) {
+	i := s.pick()
+	if i == -1 {
+		return nil, errors.New("Nothing to return")
+	}
+	e := s.entries[i]
+	s.reason = fmt.Sprintf("virtual time %.2f, the lowest of %d ready", e.virtualTime, len(s.entries))
+	s.entries = append(s.entries[:i:i], s.entries[i+1:]...)
+	s.clock = e.virtualTime
+	s.charged[e.process.ID] = e.virtualTime + 1/e.process.ShareWeight()
+	return e.process, nil
+}
+
+// Remove drops p, and the virtual time it was charged if it's dispatched, without moving the clock
+func (s *WFQScheduler) Remove(p *Process) bool {
+	delete(s.charged, p.ID)
+	for i, e := range s.entries {
+		if e.process == p {
+			s.entries = append(s.entries[:i:i], s.entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *WFQScheduler) Reason() string {
+	return s.reason
+}
+
+func (s *WFQScheduler) Read() (*Process, error) {
+	i := s.pick()
+	if i == -1 {
+		return nil, errors.New("Nothing to return")
+	}
+	return s.entries[i].process, nil
+}
+
+func (s *WFQScheduler) Len() int {
+	return len(s.entries)
+}
+
+func (s *WFQScheduler) Name() string {
+	return s.name
+}
+
+func (s *WFQScheduler) Processes() Processes {
+	processes := Processes{}
+	for _, e := range s.entries {
+		processes = append(processes, e.process)
+	}
+	return processes
+}
+
+func (s *WFQScheduler) String() []string {
+	stringSlice := []string{}
+	for _, e := range s.entries {
+		stringSlice = append(stringSlice, fmt.Sprintf(" ['%s', w%d, %2dKB] %.3s ", e.process.Name, e.process.EffectiveWeight(), e.process.SizeInKB, s.name))
+	}
+	return stringSlice
+}
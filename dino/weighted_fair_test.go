@@ -0,0 +1,97 @@
+package dino
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWFQScheduler(t *testing.T) {
+	heavy := testProcess()
+	heavy.Weight = 2
+	light := testProcess()
+	light.Weight = 1
+	unweighted := testProcess()
+
+	wfq := NewWFQScheduler("WFQ")
+	assert.NoError(t, wfq.Add(light))
+	assert.NoError(t, wfq.Add(heavy))
+	assert.NoError(t, wfq.Add(unweighted))
+	assert.Error(t, wfq.Add(nil))
+	assert.Equal(t, 3, wfq.Len())
+
+	cpuSteps := map[*Process]int{}
+	for i := 0; i < 400; i++ {
+		p, err := wfq.Get()
+		assert.NoError(t, err)
+		cpuSteps[p]++
+		wfq.Add(p)
+	}
+	assert.Equal(t, 200, cpuSteps[heavy])
+	assert.InDelta(t, 2.0, float64(cpuSteps[heavy])/float64(cpuSteps[light]), 0.05)
+	assert.InDelta(t, 1.0, float64(cpuSteps[unweighted])/float64(cpuSteps[light]), 0.05)
+}
+
+func TestWFQNice(t *testing.T) {
+	d := NewSeeded(100, 1)
+	d.DisableArrivals = true
+	d.DisableIO = true
+	assert.NoError(t, d.SetScheduler(NewWFQScheduler("WFQ")))
+
+	long := make(Bursts, 200)
+	for i := range long {
+		long[i] = BT_CPU
+	}
+	nice := &Process{ID: "nice", Name: "nice", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: long, MemoryAddress: -1, Nice: 5}
+	greedy := &Process{ID: "greedy", Name: "greedy", Type: PT_INTERACTIVE, SizeInKB: 10, Bursts: append(Bursts{}, long...), MemoryAddress: -1, Nice: -5}
+	d.Inject(nice)
+	d.Inject(greedy)
+	for i := 0; i < 60; i++ {
+		d.Step()
+	}
+
+	cpuTime := d.CPUTimeByProcess()
+	assert.Equal(t, 60, cpuTime["nice"]+cpuTime["greedy"])
+	assert.True(t, cpuTime["greedy"] > cpuTime["nice"])
+	assert.InDelta(t, math.Pow(1.25, 10), float64(cpuTime["greedy"])/float64(cpuTime["nice"]), 1)
+}
+
+func TestEffectiveNice(t *testing.T) {
+	p := testProcess()
+	assert.Equal(t, 0, p.EffectiveNice())
+	assert.Equal(t, 1.0, p.ShareWeight())
+
+	p.Nice = -40
+	assert.Equal(t, MIN_NICE, p.EffectiveNice())
+	p.Nice = 40
+	assert.Equal(t, MAX_NICE, p.EffectiveNice())
+	p.Nice, p.Weight = 1, 5
+	assert.Equal(t, 4.0, p.ShareWeight())
+}
+
+func TestWFQRemove(t *testing.T) {
+	a, b, c := testProcess(), testProcess(), testProcess()
+	wfq := NewWFQScheduler("WFQ")
+	wfq.Add(a)
+	wfq.Add(b)
+	wfq.Add(c)
+	p, _ := wfq.Get()
+	wfq.Add(p) // a is charged a step
+	times := map[*Process]float64{}
+	for _, e := range wfq.entries {
+		times[e.process] = e.virtualTime
+	}
+
+	assert.True(t, wfq.Remove(b))
+	assert.False(t, wfq.Remove(b))
+	assert.Equal(t, Processes{c, a}, wfq.Processes())
+	for _, e := range wfq.entries {
+		assert.Equal(t, times[e.process], e.virtualTime, "Removing b charges no one")
+	}
+
+	p, _ = wfq.Get()
+	assert.Equal(t, c, p)
+	assert.False(t, wfq.Remove(c))
+	assert.Empty(t, wfq.charged, "A dispatched process is forgotten")
+}
@@ -0,0 +1,82 @@
+package dino
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrZoneFull is returned by AllocateInZone without fallback when the zone has no hole the process fits in
+var ErrZoneFull = errors.New("Cannot allocate -- zone is full")
+
+// ZonedMemory wraps a Memory split in zones of about the same size, like the nodes of a NUMA machine, in address order
+type ZonedMemory struct {
+	Memory
+	zones int
+}
+
+// NewZonedMemory splits m in n zones
+func NewZonedMemory(m Memory, n int) (*ZonedMemory, error) {
+	if n < 1 || n > len(m) {
+		return nil, fmt.Errorf("Cannot set zones -- zone count should be between 1 and %d", len(m))
+	}
+	return &ZonedMemory{Memory: m, zones: n}, nil
+}
+
+// ZoneCount returns how many zones the memory is split in
+func (zm *ZonedMemory) ZoneCount() int {
+	return zm.zones
+}
+
+// ZoneBounds returns the addresses [start, end) of the given zone
+func (zm *ZonedMemory) ZoneBounds(zone int) (start, end int) {
+	return zone * len(zm.Memory) / zm.zones, (zone + 1) * len(zm.Memory) / zm.zones
+}
+
+// ZoneOf returns the zone the given address belongs to
+func (zm *ZonedMemory) ZoneOf(address int) int {
+	for zone := 0; zone < zm.zones; zone++ {
+		if _, end := zm.ZoneBounds(zone); address < end {
+			return zone
+		}
+	}
+	return zm.zones - 1
+}
+
+// AllocateInZone allocates p in the lowest-addressed hole of zone where it fits whole. With fallback, when zone is
+// full it tries the other zones, nearest first
+func (zm *ZonedMemory) AllocateInZone(p *Process, zone int, fallback bool) error {
+	if p == nil {
+		return ErrNilProcess
+	} else if zone < 0 || zone >= zm.zones {
+		return fmt.Errorf("Cannot allocate -- zone %d doesn't exist, memory has %d zones", zone, zm.zones)
+	}
+	zones := []int{zone}
+	for distance := 1; fallback && distance < zm.zones; distance++ {
+		for _, z := range []int{zone - distance, zone + distance} {
+			if z >= 0 && z < zm.zones {
+				zones = append(zones, z)
+			}
+		}
+	}
+
+	holes := zm.Reachable(p).holes()
+	for _, z := range zones {
+		zoneStart, zoneEnd := zm.ZoneBounds(z)
+		for _, hole := range holes {
+			start, end := hole.Start, hole.Start+hole.Size
+			if start < zoneStart {
+				start = zoneStart
+			}
+			if end > zoneEnd {
+				end = zoneEnd
+			}
+			if end-start >= p.SizeInKB {
+				return zm.Allocate(p, start)
+			}
+		}
+	}
+	if fallback {
+		return ErrNoContiguousSpace
+	}
+	return &allocationError{fmt.Sprintf("Cannot allocate -- no hole of %d KB in zone %d", p.SizeInKB, zone), ErrZoneFull}
+}
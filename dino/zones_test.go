@@ -0,0 +1,41 @@
+package dino
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllocateInZone(t *testing.T) {
+	_, err := NewZonedMemory(make(Memory, 100), 0)
+	assert.Error(t, err)
+	m, err := NewZonedMemory(make(Memory, 100), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, m.ZoneCount())
+	start, end := m.ZoneBounds(1)
+	assert.Equal(t, []int{50, 100}, []int{start, end})
+
+	a := &Process{ID: "a", SizeInKB: 30, MemoryAddress: -1}
+	b := &Process{ID: "b", SizeInKB: 30, MemoryAddress: -1}
+	c := &Process{ID: "c", SizeInKB: 30, MemoryAddress: -1}
+	assert.NoError(t, m.AllocateInZone(a, 1, false))
+	assert.Equal(t, 50, a.MemoryAddress)
+	assert.Equal(t, 1, m.ZoneOf(a.MemoryAddress))
+
+	// The 20 KB left in zone 1 don't fit b
+	assert.True(t, errors.Is(m.AllocateInZone(b, 1, false), ErrZoneFull))
+	assert.False(t, b.IsAllocated)
+	assert.NoError(t, m.AllocateInZone(b, 1, true))
+	assert.Equal(t, 0, m.ZoneOf(b.MemoryAddress), "It falls back to zone 0")
+
+	assert.Equal(t, ErrNoContiguousSpace, m.AllocateInZone(c, 0, true))
+	assert.Error(t, m.AllocateInZone(c, 2, true))
+	assert.Equal(t, 1, m.ZoneOf(99))
+
+	// The zones belong to the wrapper, not to the cells: views of the same memory aren't split
+	single, err := NewZonedMemory(m.Memory, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, single.ZoneOf(99))
+	assert.Equal(t, 1, m.ZoneOf(99))
+}
@@ -123,9 +123,12 @@ func main() {
 			cpuExec.PaddingLeft = 8
 			cpuExec.Text = "Not executed"
 		}
-		if state.ExecutedByIO != nil {
+		if len(state.ExecutedByIO) != 0 {
 			ioExec.PaddingLeft = 6
-			ioExec.Text = "Executed: " + state.ExecutedByIO.Name
+			ioExec.Text = "Executed:"
+			for _, p := range state.ExecutedByIO {
+				ioExec.Text += " " + p.Name
+			}
 		} else {
 			ioExec.PaddingLeft = 8
 			ioExec.Text = "Not executed"
@@ -138,18 +141,11 @@ func main() {
 			frag.Text = "No!"
 		}
 		memString := ""
-		for i, _ := range d.Memory {
+		for i, mark := range d.Memory.Map() {
 			if i%10 == 0 {
 				memString += "\n"
 			}
-
-			mark := "o"
-			if d.Memory[i] != nil {
-				mark = "X"
-			} else {
-				mark = "-"
-			}
-			memString += mark
+			memString += string(mark)
 		}
 
 		memLayout.Text = memString